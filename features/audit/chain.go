@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+type chainRecord struct {
+	PrevHash   string         `json:"prev_hash"`
+	CreatedAt  string         `json:"created_at"`
+	ActorID    uint           `json:"actor_id"`
+	Actor      string         `json:"actor"`
+	Action     string         `json:"action"`
+	TargetType string         `json:"target_type"`
+	TargetID   string         `json:"target_id"`
+	Before     map[string]any `json:"before"`
+	After      map[string]any `json:"after"`
+	Reason     string         `json:"reason"`
+	IPAddress  string         `json:"ip_address"`
+	RequestID  string         `json:"request_id"`
+}
+
+func Hash(prevHash string, entry Entry) string {
+	var record = chainRecord{
+		PrevHash:   prevHash,
+		CreatedAt:  entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ActorID:    entry.ActorID,
+		Actor:      entry.Actor,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     entry.Before,
+		After:      entry.After,
+		Reason:     entry.Reason,
+		IPAddress:  entry.IPAddress,
+		RequestID:  entry.RequestID,
+	}
+
+	raw, _ := json.Marshal(record)
+	var sum = sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
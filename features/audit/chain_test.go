@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func testEntry() Entry {
+	return Entry{
+		ID:         1,
+		ActorID:    7,
+		Actor:      "admin",
+		Action:     ActionRoleAssign,
+		TargetType: TargetUser,
+		TargetID:   "42",
+		Before:     map[string]any{"roles": []any{"customer"}},
+		After:      map[string]any{"roles": []any{"customer", "staff"}},
+		Reason:     "promotion",
+		IPAddress:  "10.0.0.1",
+		RequestID:  "req-1",
+		CreatedAt:  time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC),
+	}
+}
+
+func TestHash(t *testing.T) {
+	var base = Hash("", testEntry())
+	if len(base) != 64 {
+		t.Fatalf("Hash() length = %d, want 64", len(base))
+	}
+
+	var tests = []struct {
+		name     string
+		prevHash string
+		edit     func(entry *Entry)
+		wantSame bool
+	}{
+		{name: "same entry", wantSame: true},
+		{name: "id and stored hashes are not hashed", edit: func(entry *Entry) { entry.ID = 99; entry.Hash = "x"; entry.PrevHash = "y" }, wantSame: true},
+		{name: "created at in another zone", edit: func(entry *Entry) { entry.CreatedAt = entry.CreatedAt.In(time.FixedZone("WIB", 7*3600)) }, wantSame: true},
+		{name: "previous hash", prevHash: base},
+		{name: "created at", edit: func(entry *Entry) { entry.CreatedAt = entry.CreatedAt.Add(time.Microsecond) }},
+		{name: "actor id", edit: func(entry *Entry) { entry.ActorID = 8 }},
+		{name: "actor", edit: func(entry *Entry) { entry.Actor = "root" }},
+		{name: "action", edit: func(entry *Entry) { entry.Action = ActionRoleRevoke }},
+		{name: "target type", edit: func(entry *Entry) { entry.TargetType = TargetRole }},
+		{name: "target id", edit: func(entry *Entry) { entry.TargetID = "43" }},
+		{name: "before", edit: func(entry *Entry) { entry.Before = nil }},
+		{name: "after", edit: func(entry *Entry) { entry.After = map[string]any{"roles": []any{"admin"}} }},
+		{name: "reason", edit: func(entry *Entry) { entry.Reason = "" }},
+		{name: "ip address", edit: func(entry *Entry) { entry.IPAddress = "10.0.0.2" }},
+		{name: "request id", edit: func(entry *Entry) { entry.RequestID = "req-2" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entry = testEntry()
+			if tt.edit != nil {
+				tt.edit(&entry)
+			}
+
+			if got := Hash(tt.prevHash, entry) == base; got != tt.wantSame {
+				t.Errorf("Hash() unchanged = %v, want %v", got, tt.wantSame)
+			}
+		})
+	}
+}
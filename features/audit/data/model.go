@@ -0,0 +1,29 @@
+package data
+
+import "time"
+
+type AuditLog struct {
+	ID         uint      `gorm:"column:id;primaryKey"`
+	CreatedAt  time.Time `gorm:"column:created_at;type:timestamptz;index;not null"`
+	ActorID    uint      `gorm:"column:actor_id;index"`
+	Actor      string    `gorm:"column:actor;type:varchar(255)"`
+	Action     string    `gorm:"column:action;type:varchar(64);index;not null"`
+	TargetType string    `gorm:"column:target_type;type:varchar(32);index:idx_audit_logs_target,priority:1"`
+	TargetID   string    `gorm:"column:target_id;type:varchar(64);index:idx_audit_logs_target,priority:2"`
+	Before     *string   `gorm:"column:before;type:jsonb"`
+	After      *string   `gorm:"column:after;type:jsonb"`
+	Reason     string    `gorm:"column:reason;type:varchar(255)"`
+	IPAddress  string    `gorm:"column:ip_address;type:varchar(64)"`
+	RequestID  string    `gorm:"column:request_id;type:varchar(64);index"`
+	PrevHash   string    `gorm:"column:prev_hash;type:varchar(64)"`
+	Hash       string    `gorm:"column:hash;type:varchar(64);index"`
+}
+
+type AuditCheckpoint struct {
+	ID        uint      `gorm:"column:id;primaryKey"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;not null"`
+	LastLogID uint      `gorm:"column:last_log_id;index;not null"`
+	LastHash  string    `gorm:"column:last_hash;type:varchar(64);not null"`
+	Count     int64     `gorm:"column:count;not null"`
+	Signature string    `gorm:"column:signature;type:varchar(64);not null"`
+}
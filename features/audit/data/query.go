@@ -0,0 +1,346 @@
+package data
+
+import (
+	"e-ticketing-gin/features/audit"
+	"encoding/json"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	exportBatchSize = 500
+	walkBatchSize   = 1000
+	chainLockKey    = 7246150
+)
+
+type AuditData struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *AuditData {
+	return &AuditData{
+		db: db,
+	}
+}
+
+func (ad *AuditData) Insert(entry audit.Entry) error {
+	var dbData = new(AuditLog)
+	dbData.ActorID = entry.ActorID
+	dbData.Actor = entry.Actor
+	dbData.Action = entry.Action
+	dbData.TargetType = entry.TargetType
+	dbData.TargetID = entry.TargetID
+	dbData.Reason = entry.Reason
+	dbData.IPAddress = entry.IPAddress
+	dbData.RequestID = entry.RequestID
+
+	var err error
+	if dbData.Before, err = encodeDiff(entry.Before); err != nil {
+		return err
+	}
+	if dbData.After, err = encodeDiff(entry.After); err != nil {
+		return err
+	}
+
+	return ad.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", chainLockKey).Error; err != nil {
+			logrus.Error("DATA : Lock Audit Chain Error : ", err.Error())
+			return err
+		}
+
+		var prevHash string
+		var qry = tx.Model(&AuditLog{}).Select("hash").Order("id DESC").Limit(1).Find(&prevHash)
+		if err := qry.Error; err != nil {
+			logrus.Error("DATA : Get Audit Chain Head Error : ", err.Error())
+			return err
+		}
+
+		dbData.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		dbData.PrevHash = prevHash
+		dbData.Hash = audit.Hash(prevHash, logToEntity(*dbData))
+
+		if err := tx.Create(dbData).Error; err != nil {
+			logrus.Error("DATA : Insert Audit Log Error : ", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (ad *AuditData) GetEntries(query audit.Query) (*audit.Page, error) {
+	var result = new(audit.Page)
+
+	if err := ad.db.Model(&AuditLog{}).Scopes(auditFilter(query)).Count(&result.Total).Error; err != nil {
+		logrus.Error("DATA : Count Audit Logs Error : ", err.Error())
+		return nil, err
+	}
+
+	var dbData []AuditLog
+	var qry = ad.db.Scopes(auditFilter(query)).
+		Order("id DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit)
+
+	if err := qry.Find(&dbData).Error; err != nil {
+		logrus.Error("DATA : Get Audit Logs Error : ", err.Error())
+		return nil, err
+	}
+
+	result.Entries = []audit.Entry{}
+	for _, val := range dbData {
+		result.Entries = append(result.Entries, logToEntity(val))
+	}
+
+	return result, nil
+}
+
+func (ad *AuditData) Export(query audit.Query, limit int, fn func([]audit.Entry) error) error {
+	var dbData []AuditLog
+
+	var qry = ad.db.Scopes(auditFilter(query)).Limit(limit).FindInBatches(&dbData, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		var entries []audit.Entry
+		for _, val := range dbData {
+			entries = append(entries, logToEntity(val))
+		}
+		return fn(entries)
+	})
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Export Audit Logs Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ad *AuditData) Walk(fn func([]audit.Entry) error) error {
+	var dbData []AuditLog
+
+	var qry = ad.db.FindInBatches(&dbData, walkBatchSize, func(tx *gorm.DB, batch int) error {
+		var entries []audit.Entry
+		for _, val := range dbData {
+			entries = append(entries, logToEntity(val))
+		}
+		return fn(entries)
+	})
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Walk Audit Logs Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ad *AuditData) GetHead() (*audit.Head, error) {
+	var result = new(audit.Head)
+
+	var err = ad.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", chainLockKey).Error; err != nil {
+			return err
+		}
+
+		var dbData = new(AuditLog)
+		if err := tx.Select("id", "hash").Order("id DESC").Take(dbData).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		result.LastLogID = dbData.ID
+		result.LastHash = dbData.Hash
+
+		return tx.Model(&AuditLog{}).Where("id <= ?", dbData.ID).Count(&result.Count).Error
+	})
+	if err != nil {
+		logrus.Error("DATA : Get Audit Chain Head Error : ", err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (ad *AuditData) GetLatestCheckpoint() (*audit.Checkpoint, error) {
+	var dbData = new(AuditCheckpoint)
+
+	if err := ad.db.Order("id DESC").Take(dbData).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ERROR Checkpoint Not Found")
+		}
+		logrus.Error("DATA : Get Latest Audit Checkpoint Error : ", err.Error())
+		return nil, err
+	}
+
+	var result = checkpointToEntity(*dbData)
+	return &result, nil
+}
+
+func (ad *AuditData) GetCheckpoints() ([]audit.Checkpoint, error) {
+	var dbData []AuditCheckpoint
+
+	if err := ad.db.Order("id ASC").Find(&dbData).Error; err != nil {
+		logrus.Error("DATA : Get Audit Checkpoints Error : ", err.Error())
+		return nil, err
+	}
+
+	var result []audit.Checkpoint
+	for _, val := range dbData {
+		result = append(result, checkpointToEntity(val))
+	}
+
+	return result, nil
+}
+
+func (ad *AuditData) InsertCheckpoint(checkpoint audit.Checkpoint) error {
+	var dbData = new(AuditCheckpoint)
+	dbData.CreatedAt = checkpoint.CreatedAt
+	dbData.LastLogID = checkpoint.LastLogID
+	dbData.LastHash = checkpoint.LastHash
+	dbData.Count = checkpoint.Count
+	dbData.Signature = checkpoint.Signature
+
+	if err := ad.db.Create(dbData).Error; err != nil {
+		logrus.Error("DATA : Insert Audit Checkpoint Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func Backfill(db *gorm.DB) error {
+	var pending int64
+	if err := db.Model(&AuditLog{}).Where("hash IS NULL OR hash = ''").Count(&pending).Error; err != nil {
+		return err
+	}
+
+	if pending == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", chainLockKey).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("ALTER TABLE audit_logs DISABLE TRIGGER USER").Error; err != nil {
+			return err
+		}
+
+		var prevHash string
+		var dbData []AuditLog
+		var qry = tx.FindInBatches(&dbData, walkBatchSize, func(batchTx *gorm.DB, batch int) error {
+			for _, val := range dbData {
+				if val.Hash == "" {
+					val.CreatedAt = val.CreatedAt.UTC().Truncate(time.Microsecond)
+					val.PrevHash = prevHash
+					val.Hash = audit.Hash(prevHash, logToEntity(val))
+
+					var update = tx.Model(&AuditLog{}).Where("id = ?", val.ID).Updates(map[string]any{
+						"prev_hash": val.PrevHash,
+						"hash":      val.Hash,
+					})
+					if err := update.Error; err != nil {
+						return err
+					}
+				}
+				prevHash = val.Hash
+			}
+			return nil
+		})
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		return tx.Exec("ALTER TABLE audit_logs ENABLE TRIGGER USER").Error
+	})
+}
+
+func auditFilter(query audit.Query) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if query.ActorID != nil {
+			db = db.Where("actor_id = ?", *query.ActorID)
+		}
+		if query.Action != "" {
+			db = db.Where("action = ?", query.Action)
+		}
+		if query.TargetType != "" {
+			db = db.Where("target_type = ?", query.TargetType)
+		}
+		if query.TargetID != "" {
+			db = db.Where("target_id = ?", query.TargetID)
+		}
+		if query.RequestID != "" {
+			db = db.Where("request_id = ?", query.RequestID)
+		}
+		if query.From != nil {
+			db = db.Where("created_at >= ?", *query.From)
+		}
+		if query.To != nil {
+			db = db.Where("created_at < ?", *query.To)
+		}
+		return db
+	}
+}
+
+func encodeDiff(diff map[string]any) (*string, error) {
+	if len(diff) == 0 {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(diff)
+	if err != nil {
+		logrus.Error("DATA : Encode Audit Diff Error : ", err.Error())
+		return nil, err
+	}
+
+	var result = string(raw)
+	return &result, nil
+}
+
+func decodeDiff(raw *string) map[string]any {
+	if raw == nil {
+		return nil
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal([]byte(*raw), &result); err != nil {
+		logrus.Error("DATA : Decode Audit Diff Error : ", err.Error())
+		return nil
+	}
+
+	return result
+}
+
+func logToEntity(dbData AuditLog) audit.Entry {
+	var result = audit.Entry{}
+	result.ID = dbData.ID
+	result.ActorID = dbData.ActorID
+	result.Actor = dbData.Actor
+	result.Action = dbData.Action
+	result.TargetType = dbData.TargetType
+	result.TargetID = dbData.TargetID
+	result.Before = decodeDiff(dbData.Before)
+	result.After = decodeDiff(dbData.After)
+	result.Reason = dbData.Reason
+	result.IPAddress = dbData.IPAddress
+	result.RequestID = dbData.RequestID
+	result.PrevHash = dbData.PrevHash
+	result.Hash = dbData.Hash
+	result.CreatedAt = dbData.CreatedAt
+	return result
+}
+
+func checkpointToEntity(dbData AuditCheckpoint) audit.Checkpoint {
+	var result = audit.Checkpoint{}
+	result.ID = dbData.ID
+	result.LastLogID = dbData.LastLogID
+	result.LastHash = dbData.LastHash
+	result.Count = dbData.Count
+	result.Signature = dbData.Signature
+	result.CreatedAt = dbData.CreatedAt
+	return result
+}
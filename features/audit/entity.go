@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"e-ticketing-gin/helper/jwt"
+	"e-ticketing-gin/helper/requestid"
+	"github.com/gin-gonic/gin"
+	"io"
+	"time"
+)
+
+const (
+	ActionLogin                = "auth.login"
+	ActionLoginFailed          = "auth.login_failed"
+	ActionPasswordResetRequest = "auth.password_reset_request"
+	ActionPasswordReset        = "auth.password_reset"
+	ActionPasswordChange       = "auth.password_change"
+	ActionAccountDelete        = "account.delete"
+	ActionUserActivate         = "user.activate"
+	ActionUserDeactivate       = "user.deactivate"
+	ActionUserUnlock           = "user.unlock"
+	ActionUserDelete           = "user.delete"
+	ActionUserRestore          = "user.restore"
+	ActionUserImport           = "user.import"
+	ActionUserExport           = "user.export"
+	ActionSessionRevoke        = "session.revoke"
+	ActionRoleAssign           = "role.assign"
+	ActionRoleRevoke           = "role.revoke"
+	ActionRoleMFAPolicy        = "role.mfa_policy"
+
+	TargetUser    = "user"
+	TargetSession = "session"
+	TargetRole    = "role"
+)
+
+type Meta struct {
+	ActorID   uint
+	Actor     string
+	IPAddress string
+	RequestID string
+	Reason    string
+}
+
+var System = Meta{Actor: "system"}
+
+func NewMeta(c *gin.Context) Meta {
+	var meta = Meta{
+		IPAddress: c.ClientIP(),
+		RequestID: requestid.Get(c),
+		Reason:    c.Query("reason"),
+	}
+
+	if principal, ok := jwt.GetPrincipal(c); ok {
+		meta.ActorID = principal.ID
+		meta.Actor = principal.Username
+	}
+
+	return meta
+}
+
+type Entry struct {
+	ID         uint           `json:"id"`
+	ActorID    uint           `json:"actor_id"`
+	Actor      string         `json:"actor"`
+	Action     string         `json:"action"`
+	TargetType string         `json:"target_type"`
+	TargetID   string         `json:"target_id"`
+	Before     map[string]any `json:"before,omitempty"`
+	After      map[string]any `json:"after,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	IPAddress  string         `json:"ip_address"`
+	RequestID  string         `json:"request_id"`
+	PrevHash   string         `json:"prev_hash"`
+	Hash       string         `json:"hash"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type Checkpoint struct {
+	ID        uint      `json:"id"`
+	LastLogID uint      `json:"last_log_id"`
+	LastHash  string    `json:"last_hash"`
+	Count     int64     `json:"count"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Head struct {
+	LastLogID uint
+	LastHash  string
+	Count     int64
+}
+
+type Verification struct {
+	Valid        bool      `json:"valid"`
+	Checked      int64     `json:"checked"`
+	Checkpoints  int       `json:"checkpoints"`
+	BrokenID     uint      `json:"broken_id,omitempty"`
+	CheckpointID uint      `json:"checkpoint_id,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	VerifiedAt   time.Time `json:"verified_at"`
+}
+
+type Query struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	Limit      int
+}
+
+type Page struct {
+	Entries []Entry
+	Total   int64
+}
+
+type AuditHandlerInterface interface {
+	GetEntries(c *gin.Context)
+	Export(c *gin.Context)
+	Verify(c *gin.Context)
+}
+
+type AuditServiceInterface interface {
+	Record(meta Meta, action, targetType, targetID string, before, after map[string]any)
+	GetEntries(query Query) (*Page, error)
+	Export(query Query, w io.Writer) error
+	Checkpoint() error
+	Verify() (*Verification, error)
+}
+
+type AuditDataInterface interface {
+	Insert(entry Entry) error
+	GetEntries(query Query) (*Page, error)
+	Export(query Query, limit int, fn func([]Entry) error) error
+	Walk(fn func([]Entry) error) error
+	GetHead() (*Head, error)
+	GetLatestCheckpoint() (*Checkpoint, error)
+	GetCheckpoints() ([]Checkpoint, error)
+	InsertCheckpoint(checkpoint Checkpoint) error
+}
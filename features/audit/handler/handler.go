@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"e-ticketing-gin/features/audit"
+	"e-ticketing-gin/helper"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+type AuditHandler struct {
+	service audit.AuditServiceInterface
+}
+
+func NewHandler(service audit.AuditServiceInterface) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+	}
+}
+
+func (a *AuditHandler) GetEntries(c *gin.Context) {
+	query, ok := bindQuery(c)
+	if !ok {
+		return
+	}
+
+	res, err := a.service.GetEntries(*query)
+	if err != nil {
+		logrus.Error("Handler : Get Audit Logs Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Get Audit Logs Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponsePagination("Success Get Audit Logs", res.Entries, helper.NewPagination(query.Page, query.Limit, res.Total, "")))
+}
+
+func (a *AuditHandler) Export(c *gin.Context) {
+	query, ok := bindQuery(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"audit-%s.csv\"", time.Now().Format("20060102-150405")))
+	c.Status(http.StatusOK)
+
+	if err := a.service.Export(*query, c.Writer); err != nil {
+		logrus.Error("Handler : Export Audit Logs Error : ", err.Error())
+	}
+}
+
+func (a *AuditHandler) Verify(c *gin.Context) {
+	res, err := a.service.Verify()
+	if err != nil {
+		logrus.Error("Handler : Verify Audit Chain Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Verify Audit Chain Error", nil))
+		return
+	}
+
+	if !res.Valid {
+		c.JSON(http.StatusConflict, helper.FormatResponse("Audit Chain Broken", res))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Audit Chain Intact", res))
+}
+
+func bindQuery(c *gin.Context) (*audit.Query, bool) {
+	var input = new(GetEntriesInput)
+	if err := c.ShouldBindQuery(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return nil, false
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return nil, false
+	}
+
+	var query = audit.Query{
+		Action:     input.Action,
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+		RequestID:  input.RequestID,
+		Page:       input.Page,
+		Limit:      input.Limit,
+	}
+
+	if input.ActorID != 0 {
+		query.ActorID = &input.ActorID
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+
+	if input.From != "" {
+		from, _ := time.ParseInLocation("2006-01-02", input.From, time.Local)
+		query.From = &from
+	}
+
+	if input.To != "" {
+		to, _ := time.ParseInLocation("2006-01-02", input.To, time.Local)
+		to = to.AddDate(0, 0, 1)
+		query.To = &to
+	}
+
+	return &query, true
+}
@@ -0,0 +1,13 @@
+package handler
+
+type GetEntriesInput struct {
+	ActorID    uint   `form:"actor_id"`
+	Action     string `form:"action"`
+	TargetType string `form:"target_type" validate:"omitempty,oneof=user session role"`
+	TargetID   string `form:"target_id"`
+	RequestID  string `form:"request_id"`
+	From       string `form:"from" validate:"omitempty,datetime=2006-01-02"`
+	To         string `form:"to" validate:"omitempty,datetime=2006-01-02"`
+	Page       int    `form:"page" validate:"omitempty,min=1"`
+	Limit      int    `form:"limit" validate:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"e-ticketing-gin/configs"
+	"e-ticketing-gin/features/audit"
+	"e-ticketing-gin/helper"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AuditService struct {
+	data             audit.AuditDataInterface
+	exportMaxRows    int
+	checkpointSecret string
+}
+
+var exportHeader = []string{"id", "created_at", "actor_id", "actor", "action", "target_type", "target_id", "before", "after", "reason", "ip_address", "request_id", "hash"}
+
+var errChainBroken = errors.New("ERROR Audit Chain Broken")
+
+func New(d audit.AuditDataInterface, c *configs.ProgramConfig) *AuditService {
+	return &AuditService{
+		data:             d,
+		exportMaxRows:    c.AuditExportMaxRows,
+		checkpointSecret: c.AuditCheckpointSecret,
+	}
+}
+
+func (a *AuditService) Record(meta audit.Meta, action, targetType, targetID string, before, after map[string]any) {
+	var entry = audit.Entry{
+		ActorID:    meta.ActorID,
+		Actor:      meta.Actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     before,
+		After:      after,
+		Reason:     meta.Reason,
+		IPAddress:  meta.IPAddress,
+		RequestID:  meta.RequestID,
+	}
+
+	if err := a.data.Insert(entry); err != nil {
+		logrus.Error("Service : Record Audit Log Error : ", action, " : ", err.Error())
+	}
+}
+
+func (a *AuditService) GetEntries(query audit.Query) (*audit.Page, error) {
+	res, err := a.data.GetEntries(query)
+	if err != nil {
+		logrus.Error("Service : Get Audit Logs Error : ", err.Error())
+		return nil, errors.New("ERROR Get Audit Logs")
+	}
+
+	return res, nil
+}
+
+func (a *AuditService) Export(query audit.Query, w io.Writer) error {
+	var writer = csv.NewWriter(w)
+	if err := writer.Write(exportHeader); err != nil {
+		return err
+	}
+
+	err := a.data.Export(query, a.exportMaxRows, func(entries []audit.Entry) error {
+		for _, val := range entries {
+			if err := writer.Write(exportRow(val)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		logrus.Error("Service : Export Audit Logs Error : ", err.Error())
+		return errors.New("ERROR Export Audit Logs")
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (a *AuditService) Checkpoint() error {
+	head, err := a.data.GetHead()
+	if err != nil {
+		logrus.Error("Service : Get Audit Chain Head Error : ", err.Error())
+		return errors.New("ERROR Audit Checkpoint")
+	}
+
+	if head.LastLogID == 0 {
+		return nil
+	}
+
+	latest, err := a.data.GetLatestCheckpoint()
+	if err != nil && !strings.Contains(err.Error(), "Not Found") {
+		logrus.Error("Service : Get Latest Audit Checkpoint Error : ", err.Error())
+		return errors.New("ERROR Audit Checkpoint")
+	}
+
+	if latest != nil && latest.LastLogID == head.LastLogID {
+		return nil
+	}
+
+	var checkpoint = audit.Checkpoint{
+		LastLogID: head.LastLogID,
+		LastHash:  head.LastHash,
+		Count:     head.Count,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	checkpoint.Signature = a.sign(checkpoint)
+
+	if err := a.data.InsertCheckpoint(checkpoint); err != nil {
+		logrus.Error("Service : Insert Audit Checkpoint Error : ", err.Error())
+		return errors.New("ERROR Audit Checkpoint")
+	}
+
+	return nil
+}
+
+func (a *AuditService) Verify() (*audit.Verification, error) {
+	var result = &audit.Verification{Valid: true}
+
+	checkpoints, err := a.data.GetCheckpoints()
+	if err != nil {
+		logrus.Error("Service : Get Audit Checkpoints Error : ", err.Error())
+		return nil, errors.New("ERROR Verify Audit Chain")
+	}
+
+	var pending = map[uint][]audit.Checkpoint{}
+	for _, val := range checkpoints {
+		if !hmac.Equal([]byte(val.Signature), []byte(a.sign(val))) {
+			fail(result, 0, val.ID, "checkpoint signature is invalid")
+			return a.verified(result), nil
+		}
+		pending[val.LastLogID] = append(pending[val.LastLogID], val)
+	}
+
+	var prevHash string
+	err = a.data.Walk(func(entries []audit.Entry) error {
+		for _, val := range entries {
+			if val.PrevHash != prevHash {
+				fail(result, val.ID, 0, "previous hash does not match the preceding record")
+				return errChainBroken
+			}
+
+			if audit.Hash(val.PrevHash, val) != val.Hash {
+				fail(result, val.ID, 0, "record hash does not match its contents")
+				return errChainBroken
+			}
+
+			result.Checked++
+			prevHash = val.Hash
+
+			for _, checkpoint := range pending[val.ID] {
+				if checkpoint.LastHash != val.Hash || checkpoint.Count != result.Checked {
+					fail(result, val.ID, checkpoint.ID, "record does not match signed checkpoint")
+					return errChainBroken
+				}
+				result.Checkpoints++
+			}
+			delete(pending, val.ID)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		logrus.Error("Service : Walk Audit Chain Error : ", err.Error())
+		return nil, errors.New("ERROR Verify Audit Chain")
+	}
+
+	if result.Valid {
+		for _, val := range checkpoints {
+			if _, found := pending[val.LastLogID]; found {
+				fail(result, val.LastLogID, val.ID, "record referenced by signed checkpoint is missing")
+				break
+			}
+		}
+	}
+
+	return a.verified(result), nil
+}
+
+func (a *AuditService) verified(result *audit.Verification) *audit.Verification {
+	result.VerifiedAt = time.Now()
+	if !result.Valid {
+		logrus.Error("Service : Audit Chain Broken : ", result.Reason, " : record ", result.BrokenID, " checkpoint ", result.CheckpointID)
+	}
+	return result
+}
+
+func fail(result *audit.Verification, brokenID, checkpointID uint, reason string) {
+	result.Valid = false
+	result.BrokenID = brokenID
+	result.CheckpointID = checkpointID
+	result.Reason = reason
+}
+
+func (a *AuditService) sign(checkpoint audit.Checkpoint) string {
+	var mac = hmac.New(sha256.New, []byte(a.checkpointSecret))
+	mac.Write([]byte(fmt.Sprintf("%d|%s|%d|%s", checkpoint.LastLogID, checkpoint.LastHash, checkpoint.Count, checkpoint.CreatedAt.UTC().Format(time.RFC3339Nano))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func exportRow(entry audit.Entry) []string {
+	var row = []string{
+		strconv.FormatUint(uint64(entry.ID), 10),
+		entry.CreatedAt.UTC().Format(time.RFC3339),
+		strconv.FormatUint(uint64(entry.ActorID), 10),
+		entry.Actor,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		encodeDiff(entry.Before),
+		encodeDiff(entry.After),
+		entry.Reason,
+		entry.IPAddress,
+		entry.RequestID,
+		entry.Hash,
+	}
+
+	for i, val := range row {
+		row[i] = helper.CSVCell(val)
+	}
+
+	return row
+}
+
+func encodeDiff(diff map[string]any) string {
+	if len(diff) == 0 {
+		return ""
+	}
+
+	raw, err := json.Marshal(diff)
+	if err != nil {
+		return ""
+	}
+
+	return string(raw)
+}
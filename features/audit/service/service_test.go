@@ -0,0 +1,207 @@
+package service
+
+import (
+	"e-ticketing-gin/configs"
+	"e-ticketing-gin/features/audit"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type fakeData struct {
+	entries     []audit.Entry
+	checkpoints []audit.Checkpoint
+}
+
+func (f *fakeData) Insert(entry audit.Entry) error {
+	var prevHash string
+	if len(f.entries) > 0 {
+		prevHash = f.entries[len(f.entries)-1].Hash
+	}
+
+	entry.ID = uint(len(f.entries) + 1)
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	entry.PrevHash = prevHash
+	entry.Hash = audit.Hash(prevHash, entry)
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func (f *fakeData) GetEntries(query audit.Query) (*audit.Page, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeData) Export(query audit.Query, limit int, fn func([]audit.Entry) error) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeData) Walk(fn func([]audit.Entry) error) error {
+	for i := 0; i < len(f.entries); i += 2 {
+		if err := fn(f.entries[i:min(i+2, len(f.entries))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeData) GetHead() (*audit.Head, error) {
+	var result = &audit.Head{Count: int64(len(f.entries))}
+	if len(f.entries) > 0 {
+		result.LastLogID = f.entries[len(f.entries)-1].ID
+		result.LastHash = f.entries[len(f.entries)-1].Hash
+	}
+	return result, nil
+}
+
+func (f *fakeData) GetLatestCheckpoint() (*audit.Checkpoint, error) {
+	if len(f.checkpoints) == 0 {
+		return nil, errors.New("ERROR Checkpoint Not Found")
+	}
+	var result = f.checkpoints[len(f.checkpoints)-1]
+	return &result, nil
+}
+
+func (f *fakeData) GetCheckpoints() ([]audit.Checkpoint, error) {
+	return f.checkpoints, nil
+}
+
+func (f *fakeData) InsertCheckpoint(checkpoint audit.Checkpoint) error {
+	checkpoint.ID = uint(len(f.checkpoints) + 1)
+	f.checkpoints = append(f.checkpoints, checkpoint)
+	return nil
+}
+
+func newTestService(records int, checkpointAt ...int) (*AuditService, *fakeData) {
+	var data = new(fakeData)
+	var service = New(data, &configs.ProgramConfig{AuditCheckpointSecret: "0123456789abcdef0123456789abcdef"})
+
+	for i := 1; i <= records; i++ {
+		service.Record(audit.System, audit.ActionUserUnlock, audit.TargetUser, strconv.Itoa(i), nil, map[string]any{"n": float64(i)})
+		for _, at := range checkpointAt {
+			if at == i {
+				service.Checkpoint()
+			}
+		}
+	}
+
+	return service, data
+}
+
+func TestCheckpoint(t *testing.T) {
+	service, data := newTestService(0)
+
+	if err := service.Checkpoint(); err != nil || len(data.checkpoints) != 0 {
+		t.Fatalf("Checkpoint() on empty chain = %v, %d checkpoints", err, len(data.checkpoints))
+	}
+
+	service.Record(audit.System, audit.ActionUserUnlock, audit.TargetUser, "1", nil, nil)
+	service.Record(audit.System, audit.ActionUserUnlock, audit.TargetUser, "2", nil, nil)
+
+	if err := service.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	if err := service.Checkpoint(); err != nil || len(data.checkpoints) != 1 {
+		t.Fatalf("Checkpoint() without new records = %v, %d checkpoints, want 1", err, len(data.checkpoints))
+	}
+
+	var checkpoint = data.checkpoints[0]
+	if checkpoint.LastLogID != 2 || checkpoint.Count != 2 || checkpoint.LastHash != data.entries[1].Hash {
+		t.Errorf("Checkpoint() = %+v", checkpoint)
+	}
+	if checkpoint.Signature != service.sign(checkpoint) {
+		t.Error("Checkpoint() signature does not verify")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var tests = []struct {
+		name           string
+		records        int
+		checkpointAt   []int
+		tamper         func(data *fakeData)
+		wantValid      bool
+		wantBrokenID   uint
+		wantCheckpoint uint
+		wantReason     string
+	}{
+		{name: "empty chain", wantValid: true},
+		{name: "intact chain with checkpoints", records: 5, checkpointAt: []int{2, 5}, wantValid: true},
+		{
+			name:         "edited record",
+			records:      5,
+			tamper:       func(data *fakeData) { data.entries[2].Actor = "mallory" },
+			wantBrokenID: 3,
+			wantReason:   "record hash does not match its contents",
+		},
+		{
+			name:    "deleted record",
+			records: 5,
+			tamper: func(data *fakeData) {
+				data.entries = append(data.entries[:2], data.entries[3:]...)
+			},
+			wantBrokenID: 4,
+			wantReason:   "previous hash does not match the preceding record",
+		},
+		{
+			name:    "rehashed tail after deletion",
+			records: 5,
+			tamper: func(data *fakeData) {
+				var entries = append([]audit.Entry(nil), data.entries[:2]...)
+				for _, val := range data.entries[3:] {
+					val.PrevHash = entries[len(entries)-1].Hash
+					val.Hash = audit.Hash(val.PrevHash, val)
+					entries = append(entries, val)
+				}
+				data.entries = entries
+			},
+			checkpointAt:   []int{4},
+			wantBrokenID:   4,
+			wantCheckpoint: 1,
+			wantReason:     "record does not match signed checkpoint",
+		},
+		{
+			name:           "truncated tail",
+			records:        4,
+			checkpointAt:   []int{4},
+			tamper:         func(data *fakeData) { data.entries = data.entries[:3] },
+			wantBrokenID:   4,
+			wantCheckpoint: 1,
+			wantReason:     "record referenced by signed checkpoint is missing",
+		},
+		{
+			name:           "forged checkpoint",
+			records:        3,
+			checkpointAt:   []int{3},
+			tamper:         func(data *fakeData) { data.checkpoints[0].Count = 2 },
+			wantCheckpoint: 1,
+			wantReason:     "checkpoint signature is invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, data := newTestService(tt.records, tt.checkpointAt...)
+			if tt.tamper != nil {
+				tt.tamper(data)
+			}
+
+			result, err := service.Verify()
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			if result.Valid != tt.wantValid || result.BrokenID != tt.wantBrokenID || result.CheckpointID != tt.wantCheckpoint || result.Reason != tt.wantReason {
+				t.Errorf("Verify() = valid %v, broken %d, checkpoint %d, reason %q; want valid %v, broken %d, checkpoint %d, reason %q",
+					result.Valid, result.BrokenID, result.CheckpointID, result.Reason,
+					tt.wantValid, tt.wantBrokenID, tt.wantCheckpoint, tt.wantReason)
+			}
+			if tt.wantValid && result.Checked != int64(tt.records) {
+				t.Errorf("Verify() checked = %d, want %d", result.Checked, tt.records)
+			}
+			if tt.wantValid && result.Checkpoints != len(tt.checkpointAt) {
+				t.Errorf("Verify() checkpoints = %d, want %d", result.Checkpoints, len(tt.checkpointAt))
+			}
+		})
+	}
+}
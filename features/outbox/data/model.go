@@ -0,0 +1,20 @@
+package data
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type EmailOutbox struct {
+	*gorm.Model
+	Recipient     string     `gorm:"column:recipient;type:varchar(255);not null"`
+	Subject       string     `gorm:"column:subject;type:varchar(255);not null"`
+	HTML          string     `gorm:"column:html;type:text"`
+	Text          string     `gorm:"column:text;type:text"`
+	Sensitive     bool       `gorm:"column:sensitive;type:bool;not null;default:false"`
+	Status        string     `gorm:"column:status;type:varchar(20);index:idx_email_outbox_due,priority:1;not null"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;type:timestamp;index:idx_email_outbox_due,priority:2;not null"`
+	LastError     string     `gorm:"column:last_error;type:text"`
+	SentAt        *time.Time `gorm:"column:sent_at;type:timestamp"`
+}
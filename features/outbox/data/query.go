@@ -0,0 +1,197 @@
+package data
+
+import (
+	"e-ticketing-gin/features/outbox"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type OutboxData struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *OutboxData {
+	return &OutboxData{
+		db: db,
+	}
+}
+
+func Enqueue(tx *gorm.DB, message outbox.Message) error {
+	var dbData = new(EmailOutbox)
+	dbData.Recipient = message.Recipient
+	dbData.Subject = message.Subject
+	dbData.HTML = message.HTML
+	dbData.Text = message.Text
+	dbData.Sensitive = message.Sensitive
+	dbData.Status = outbox.StatusPending
+	dbData.NextAttemptAt = time.Now()
+
+	if err := tx.Create(dbData).Error; err != nil {
+		logrus.Error("DATA : Enqueue Email Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (od *OutboxData) GetMessages(status string) ([]outbox.Message, error) {
+	var dbData []EmailOutbox
+
+	var qry = od.db.Select("id", "recipient", "subject", "sensitive", "status", "attempts", "next_attempt_at", "last_error", "sent_at", "created_at")
+	if status != "" {
+		qry = qry.Where("status = ?", status)
+	}
+
+	if err := qry.Order("id DESC").Limit(100).Find(&dbData).Error; err != nil {
+		logrus.Error("DATA : Get Outbox Messages Error : ", err.Error())
+		return nil, err
+	}
+
+	var result []outbox.Message
+	for _, val := range dbData {
+		result = append(result, messageToEntity(val))
+	}
+
+	return result, nil
+}
+
+func (od *OutboxData) GetMessage(id uint) (*outbox.Message, error) {
+	var dbData = new(EmailOutbox)
+
+	if err := od.db.Where("id = ?", id).First(dbData).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ERROR Message Not Found")
+		}
+		logrus.Error("DATA : Get Outbox Message Error : ", err.Error())
+		return nil, err
+	}
+
+	var result = messageToEntity(*dbData)
+	return &result, nil
+}
+
+func (od *OutboxData) ClaimDue(limit int, lease time.Duration) ([]outbox.Message, error) {
+	var dbData []EmailOutbox
+
+	err := od.db.Transaction(func(tx *gorm.DB) error {
+		var qry = tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", outbox.StatusPending, time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&dbData)
+
+		if err := qry.Error; err != nil {
+			return err
+		}
+
+		if len(dbData) == 0 {
+			return nil
+		}
+
+		var ids []uint
+		for _, val := range dbData {
+			ids = append(ids, val.ID)
+		}
+
+		return tx.Model(&EmailOutbox{}).Where("id IN ?", ids).Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+
+	if err != nil {
+		logrus.Error("DATA : Claim Outbox Messages Error : ", err.Error())
+		return nil, err
+	}
+
+	var result []outbox.Message
+	for _, val := range dbData {
+		result = append(result, messageToEntity(val))
+	}
+
+	return result, nil
+}
+
+func (od *OutboxData) MarkSent(id uint) error {
+	var qry = od.db.Model(&EmailOutbox{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     outbox.StatusSent,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"sent_at":    time.Now(),
+		"last_error": "",
+		"html":       gorm.Expr("CASE WHEN sensitive THEN '' ELSE html END"),
+		"text":       gorm.Expr("CASE WHEN sensitive THEN '' ELSE text END"),
+	})
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Mark Outbox Sent Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (od *OutboxData) MarkFailed(id uint, attempts int, nextAttemptAt time.Time, dead bool, lastError string) error {
+	var status = outbox.StatusPending
+	if dead {
+		status = outbox.StatusDead
+	}
+
+	var update = map[string]interface{}{
+		"status":          status,
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	}
+	if dead {
+		update["html"] = gorm.Expr("CASE WHEN sensitive THEN '' ELSE html END")
+		update["text"] = gorm.Expr("CASE WHEN sensitive THEN '' ELSE text END")
+	}
+
+	var qry = od.db.Model(&EmailOutbox{}).Where("id = ?", id).Updates(update)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Mark Outbox Failed Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (od *OutboxData) Requeue(id uint) error {
+	var qry = od.db.Model(&EmailOutbox{}).Where("id = ? AND status <> ? AND NOT sensitive", id, outbox.StatusPending).Updates(map[string]interface{}{
+		"status":          outbox.StatusPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"last_error":      "",
+		"sent_at":         nil,
+	})
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Requeue Outbox Message Error : ", err.Error())
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("ERROR Message Already Pending")
+	}
+
+	return nil
+}
+
+func messageToEntity(dbData EmailOutbox) outbox.Message {
+	var result = outbox.Message{}
+	result.ID = dbData.ID
+	result.Recipient = dbData.Recipient
+	result.Subject = dbData.Subject
+	result.HTML = dbData.HTML
+	result.Text = dbData.Text
+	result.Sensitive = dbData.Sensitive
+	result.Status = dbData.Status
+	result.Attempts = dbData.Attempts
+	result.NextAttemptAt = dbData.NextAttemptAt
+	result.LastError = dbData.LastError
+	result.SentAt = dbData.SentAt
+	result.CreatedAt = dbData.CreatedAt
+
+	return result
+}
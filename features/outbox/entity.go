@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusDead    = "dead"
+)
+
+type Message struct {
+	ID            uint       `json:"id"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	HTML          string     `json:"html,omitempty"`
+	Text          string     `json:"text,omitempty"`
+	Sensitive     bool       `json:"sensitive"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type OutboxHandlerInterface interface {
+	GetMessages(c *gin.Context)
+	GetMessage(c *gin.Context)
+	Resend(c *gin.Context)
+}
+
+type OutboxServiceInterface interface {
+	GetMessages(status string) ([]Message, error)
+	GetMessage(id uint) (*Message, error)
+	Resend(id uint) error
+	Deliver() error
+}
+
+type OutboxDataInterface interface {
+	GetMessages(status string) ([]Message, error)
+	GetMessage(id uint) (*Message, error)
+	ClaimDue(limit int, lease time.Duration) ([]Message, error)
+	MarkSent(id uint) error
+	MarkFailed(id uint, attempts int, nextAttemptAt time.Time, dead bool, lastError string) error
+	Requeue(id uint) error
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"e-ticketing-gin/features/outbox"
+	"e-ticketing-gin/helper"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type OutboxHandler struct {
+	service outbox.OutboxServiceInterface
+}
+
+func NewHandler(service outbox.OutboxServiceInterface) *OutboxHandler {
+	return &OutboxHandler{
+		service: service,
+	}
+}
+
+func (o *OutboxHandler) GetMessages(c *gin.Context) {
+	var status = c.Query("status")
+	if status != "" && status != outbox.StatusPending && status != outbox.StatusSent && status != outbox.StatusDead {
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Status", nil))
+		return
+	}
+
+	res, err := o.service.GetMessages(status)
+	if err != nil {
+		logrus.Error("Handler : Get Outbox Messages Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Get Outbox Messages Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Get Outbox Messages", res))
+}
+
+func (o *OutboxHandler) GetMessage(c *gin.Context) {
+	messageId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Message ID", nil))
+		return
+	}
+
+	res, err := o.service.GetMessage(uint(messageId))
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("Message Not Found", nil))
+			return
+		}
+		logrus.Error("Handler : Get Outbox Message Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Get Outbox Message Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Get Outbox Message", res))
+}
+
+func (o *OutboxHandler) Resend(c *gin.Context) {
+	messageId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Message ID", nil))
+		return
+	}
+
+	if err := o.service.Resend(uint(messageId)); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("Message Not Found", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Already Pending") {
+			c.JSON(http.StatusConflict, helper.FormatResponse("Message Already Queued", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "One-Time Code") {
+			c.JSON(http.StatusConflict, helper.FormatResponse("Message Contains One-Time Code, ask the user to request a new one", nil))
+			return
+		}
+		logrus.Error("Handler : Resend Outbox Message Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Resend Outbox Message Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Queue Message For Delivery", nil))
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"e-ticketing-gin/configs"
+	"e-ticketing-gin/features/outbox"
+	"e-ticketing-gin/helper/email"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+type OutboxService struct {
+	data        outbox.OutboxDataInterface
+	email       email.EmailInterface
+	maxAttempts int
+}
+
+const (
+	deliverBatchSize = 20
+	deliverLease     = time.Minute * 5
+	retryBaseDelay   = time.Second * 30
+	retryMaxDelay    = time.Hour * 6
+)
+
+func New(d outbox.OutboxDataInterface, em email.EmailInterface, c *configs.ProgramConfig) *OutboxService {
+	return &OutboxService{
+		data:        d,
+		email:       em,
+		maxAttempts: c.OutboxMaxAttempts,
+	}
+}
+
+func (o *OutboxService) GetMessages(status string) ([]outbox.Message, error) {
+	res, err := o.data.GetMessages(status)
+	if err != nil {
+		logrus.Error("Service : Error Get Outbox Messages : ", err.Error())
+		return nil, errors.New("ERROR Error Get Outbox Messages")
+	}
+
+	return res, nil
+}
+
+func (o *OutboxService) GetMessage(id uint) (*outbox.Message, error) {
+	res, err := o.data.GetMessage(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return nil, err
+		}
+		logrus.Error("Service : Error Get Outbox Message : ", err.Error())
+		return nil, errors.New("ERROR Error Get Outbox Message")
+	}
+
+	if res.Sensitive {
+		res.HTML = ""
+		res.Text = ""
+	}
+
+	return res, nil
+}
+
+func (o *OutboxService) Resend(id uint) error {
+	res, err := o.GetMessage(id)
+	if err != nil {
+		return err
+	}
+
+	if res.Sensitive {
+		return errors.New("ERROR Message Contains One-Time Code")
+	}
+
+	if err := o.data.Requeue(id); err != nil {
+		if strings.Contains(err.Error(), "Already Pending") {
+			return err
+		}
+		logrus.Error("Service : Error Requeue Outbox Message : ", err.Error())
+		return errors.New("ERROR Error Resend Outbox Message")
+	}
+
+	return nil
+}
+
+func (o *OutboxService) Deliver() error {
+	messages, err := o.data.ClaimDue(deliverBatchSize, deliverLease)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		var content = email.Content{
+			Subject: message.Subject,
+			HTML:    message.HTML,
+			Text:    message.Text,
+		}
+
+		errSend := o.email.SendEmail(message.Recipient, content)
+		if errSend == nil {
+			if err := o.data.MarkSent(message.ID); err != nil {
+				logrus.Error("Service : Error Mark Outbox Sent : ", err.Error())
+			}
+			continue
+		}
+
+		var attempts = message.Attempts + 1
+		var dead = attempts >= o.maxAttempts
+		if dead {
+			logrus.Error("Service : Outbox Message Dead Lettered : ", message.ID, " : ", errSend.Error())
+		}
+
+		if err := o.data.MarkFailed(message.ID, attempts, time.Now().Add(retryDelay(attempts)), dead, errSend.Error()); err != nil {
+			logrus.Error("Service : Error Mark Outbox Failed : ", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func retryDelay(attempts int) time.Duration {
+	var delay = retryBaseDelay << uint(attempts-1)
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+	return delay
+}
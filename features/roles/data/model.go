@@ -0,0 +1,26 @@
+package data
+
+import "gorm.io/gorm"
+
+type Role struct {
+	*gorm.Model
+	Name        string `gorm:"column:name;type:varchar(50);uniqueIndex;not null"`
+	Description string `gorm:"column:description;type:varchar(255)"`
+	MFARequired bool   `gorm:"column:mfa_required;type:bool;not null;default:false"`
+}
+
+type Permission struct {
+	*gorm.Model
+	Name        string `gorm:"column:name;type:varchar(100);uniqueIndex;not null"`
+	Description string `gorm:"column:description;type:varchar(255)"`
+}
+
+type RolePermission struct {
+	RoleID       uint `gorm:"column:role_id;primaryKey"`
+	PermissionID uint `gorm:"column:permission_id;primaryKey"`
+}
+
+type UserRole struct {
+	UserID uint `gorm:"column:user_id;primaryKey"`
+	RoleID uint `gorm:"column:role_id;primaryKey"`
+}
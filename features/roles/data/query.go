@@ -0,0 +1,204 @@
+package data
+
+import (
+	"e-ticketing-gin/features/roles"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleData struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *RoleData {
+	return &RoleData{
+		db: db,
+	}
+}
+
+func (rd *RoleData) GetRoles() ([]roles.Role, error) {
+	var dbData []Role
+
+	if err := rd.db.Order("id ASC").Find(&dbData).Error; err != nil {
+		logrus.Error("DATA : Get Roles Error : ", err.Error())
+		return nil, err
+	}
+
+	var result []roles.Role
+	for _, role := range dbData {
+		var permissions []string
+		var qry = rd.db.Table("role_permissions").
+			Select("permissions.name").
+			Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+			Where("role_permissions.role_id = ?", role.ID).
+			Order("permissions.name ASC").
+			Pluck("permissions.name", &permissions)
+
+		if err := qry.Error; err != nil {
+			logrus.Error("DATA : Get Role Permissions Error : ", err.Error())
+			return nil, err
+		}
+
+		result = append(result, roles.Role{
+			ID:          role.ID,
+			Name:        role.Name,
+			Description: role.Description,
+			MFARequired: role.MFARequired,
+			Permissions: permissions,
+		})
+	}
+
+	return result, nil
+}
+
+func (rd *RoleData) GetPermissions() ([]roles.Permission, error) {
+	var dbData []Permission
+
+	if err := rd.db.Order("name ASC").Find(&dbData).Error; err != nil {
+		logrus.Error("DATA : Get Permissions Error : ", err.Error())
+		return nil, err
+	}
+
+	var result []roles.Permission
+	for _, permission := range dbData {
+		result = append(result, roles.Permission{
+			ID:          permission.ID,
+			Name:        permission.Name,
+			Description: permission.Description,
+		})
+	}
+
+	return result, nil
+}
+
+func (rd *RoleData) GetUserAccess(userID uint) (*roles.UserAccess, error) {
+	var result = new(roles.UserAccess)
+
+	var qryRole = rd.db.Table("user_roles").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name ASC").
+		Pluck("roles.name", &result.Roles)
+
+	if err := qryRole.Error; err != nil {
+		logrus.Error("DATA : Get User Roles Error : ", err.Error())
+		return nil, err
+	}
+
+	var qryPermission = rd.db.Table("user_roles").
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.role_id = user_roles.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("user_roles.user_id = ?", userID).
+		Order("permissions.name ASC").
+		Pluck("permissions.name", &result.Permissions)
+
+	if err := qryPermission.Error; err != nil {
+		logrus.Error("DATA : Get User Permissions Error : ", err.Error())
+		return nil, err
+	}
+
+	var qryMFA = rd.db.Table("user_roles").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id = ? AND roles.mfa_required = ?", userID, true).
+		Select("COUNT(*) > 0").
+		Scan(&result.MFARequired)
+
+	if err := qryMFA.Error; err != nil {
+		logrus.Error("DATA : Get User MFA Policy Error : ", err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (rd *RoleData) AssignRole(userID uint, role string) error {
+	dbRole, err := roleByName(rd.db, role)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	if err := rd.db.Table("users").Where("id = ?", userID).Count(&count).Error; err != nil {
+		logrus.Error("DATA : Check User Error : ", err.Error())
+		return err
+	}
+
+	if count == 0 {
+		logrus.Error("DATA : Assign Role Error : User Not Found")
+		return errors.New("ERROR User Not Found")
+	}
+
+	return insertUserRole(rd.db, userID, dbRole.ID)
+}
+
+func Assign(tx *gorm.DB, userID uint, role string) error {
+	dbRole, err := roleByName(tx, role)
+	if err != nil {
+		return err
+	}
+
+	return insertUserRole(tx, userID, dbRole.ID)
+}
+
+func insertUserRole(db *gorm.DB, userID, roleID uint) error {
+	var newData = UserRole{UserID: userID, RoleID: roleID}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&newData).Error; err != nil {
+		logrus.Error("DATA : Assign Role Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (rd *RoleData) RevokeRole(userID uint, role string) error {
+	dbRole, err := roleByName(rd.db, role)
+	if err != nil {
+		return err
+	}
+
+	var qry = rd.db.Where("user_id = ? AND role_id = ?", userID, dbRole.ID).Delete(&UserRole{})
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Revoke Role Error : ", err.Error())
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		logrus.Error("DATA : Revoke Role Error : No Row Affected")
+		return errors.New("ERROR Role Not Assigned")
+	}
+
+	return nil
+}
+
+func (rd *RoleData) SetMFARequired(role string, required bool) error {
+	dbRole, err := roleByName(rd.db, role)
+	if err != nil {
+		return err
+	}
+
+	var qry = rd.db.Model(&Role{}).Where("id = ?", dbRole.ID).Update("mfa_required", required)
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Set MFA Required Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func roleByName(db *gorm.DB, name string) (*Role, error) {
+	var dbData = new(Role)
+
+	if err := db.Where("name = ?", name).First(dbData).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logrus.Error("DATA : Get Role Error : Role Not Found")
+			return nil, errors.New("ERROR Role Not Found")
+		}
+		logrus.Error("DATA : Get Role Error : ", err.Error())
+		return nil, err
+	}
+
+	return dbData, nil
+}
@@ -0,0 +1,118 @@
+package roles
+
+import (
+	"e-ticketing-gin/features/audit"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RoleCustomer    = "customer"
+	RoleOrganizer   = "organizer"
+	RoleGateScanner = "gate-scanner"
+	RoleFinance     = "finance"
+	RoleAdmin       = "admin"
+)
+
+const (
+	PermUsersRead       = "users:read"
+	PermUsersActivate   = "users:activate"
+	PermUsersDeactivate = "users:deactivate"
+	PermUsersUnlock     = "users:unlock"
+	PermUsersSessions   = "users:sessions"
+	PermUsersDashboard  = "users:dashboard"
+	PermUsersDelete     = "users:delete"
+	PermUsersExport     = "users:export"
+	PermUsersImport     = "users:import"
+	PermRolesRead       = "roles:read"
+	PermRolesAssign     = "roles:assign"
+	PermRolesManage     = "roles:manage"
+	PermEventsManage    = "events:manage"
+	PermTicketsPurchase = "tickets:purchase"
+	PermTicketsScan     = "tickets:scan"
+	PermPayoutsRead     = "payouts:read"
+	PermEmailsPreview   = "emails:preview"
+	PermEmailsManage    = "emails:manage"
+	PermAuditRead       = "audit:read"
+)
+
+var DefaultPermissions = map[string]string{
+	PermUsersRead:       "List and view user accounts",
+	PermUsersActivate:   "Activate user accounts",
+	PermUsersDeactivate: "Deactivate user accounts",
+	PermUsersUnlock:     "Unlock accounts locked after failed logins",
+	PermUsersSessions:   "List and revoke sessions of any user",
+	PermUsersDashboard:  "View user dashboard statistics",
+	PermUsersDelete:     "Delete and restore user accounts",
+	PermUsersExport:     "Export user accounts as CSV or NDJSON",
+	PermUsersImport:     "Bulk import user accounts from CSV",
+	PermRolesRead:       "List roles, permissions and user roles",
+	PermRolesAssign:     "Assign and revoke user roles",
+	PermRolesManage:     "Manage role policies such as mandatory two-factor authentication",
+	PermEventsManage:    "Create and manage events",
+	PermTicketsPurchase: "Purchase tickets",
+	PermTicketsScan:     "Scan tickets at the gate",
+	PermPayoutsRead:     "View organizer payouts",
+	PermEmailsPreview:   "Preview transactional email templates",
+	PermEmailsManage:    "Inspect the email outbox and resend failed messages",
+	PermAuditRead:       "View and export the audit log",
+}
+
+var DefaultRoles = map[string][]string{
+	RoleCustomer:    {PermTicketsPurchase},
+	RoleOrganizer:   {PermEventsManage, PermPayoutsRead},
+	RoleGateScanner: {PermTicketsScan},
+	RoleFinance:     {PermPayoutsRead, PermUsersRead, PermUsersDashboard},
+	RoleAdmin: {
+		PermUsersRead, PermUsersActivate, PermUsersDeactivate, PermUsersUnlock, PermUsersSessions, PermUsersDashboard, PermUsersDelete,
+		PermUsersExport, PermUsersImport,
+		PermRolesRead, PermRolesAssign, PermRolesManage, PermEventsManage, PermTicketsScan, PermPayoutsRead,
+		PermEmailsPreview, PermEmailsManage, PermAuditRead,
+	},
+}
+
+type Role struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	MFARequired bool     `json:"mfa_required"`
+	Permissions []string `json:"permissions"`
+}
+
+type Permission struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type UserAccess struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	MFARequired bool     `json:"mfa_required"`
+}
+
+type RoleHandlerInterface interface {
+	GetRoles(c *gin.Context)
+	GetPermissions(c *gin.Context)
+	GetUserRoles(c *gin.Context)
+	AssignRole(c *gin.Context)
+	RevokeRole(c *gin.Context)
+	SetMFAPolicy(c *gin.Context)
+}
+
+type RoleServiceInterface interface {
+	GetRoles() ([]Role, error)
+	GetPermissions() ([]Permission, error)
+	GetUserAccess(userID uint) (*UserAccess, error)
+	AssignRole(meta audit.Meta, userID uint, role string) error
+	RevokeRole(meta audit.Meta, userID uint, role string) error
+	SetMFARequired(meta audit.Meta, role string, required bool) error
+}
+
+type RoleDataInterface interface {
+	GetRoles() ([]Role, error)
+	GetPermissions() ([]Permission, error)
+	GetUserAccess(userID uint) (*UserAccess, error)
+	AssignRole(userID uint, role string) error
+	RevokeRole(userID uint, role string) error
+	SetMFARequired(role string, required bool) error
+}
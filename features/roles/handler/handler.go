@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"e-ticketing-gin/features/audit"
+	"e-ticketing-gin/features/roles"
+	"e-ticketing-gin/helper"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type RoleHandler struct {
+	service roles.RoleServiceInterface
+}
+
+func NewHandler(service roles.RoleServiceInterface) *RoleHandler {
+	return &RoleHandler{
+		service: service,
+	}
+}
+
+func (r *RoleHandler) GetRoles(c *gin.Context) {
+	res, err := r.service.GetRoles()
+	if err != nil {
+		logrus.Error("Handler : Get Roles Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Get Roles Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Get Roles", res))
+}
+
+func (r *RoleHandler) GetPermissions(c *gin.Context) {
+	res, err := r.service.GetPermissions()
+	if err != nil {
+		logrus.Error("Handler : Get Permissions Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Get Permissions Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Get Permissions", res))
+}
+
+func (r *RoleHandler) GetUserRoles(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
+		return
+	}
+
+	res, err := r.service.GetUserAccess(uint(userId))
+	if err != nil {
+		logrus.Error("Handler : Get User Roles Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Get User Roles Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Get User Roles", res))
+}
+
+func (r *RoleHandler) AssignRole(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
+		return
+	}
+
+	var input = new(AssignRoleInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	if err := r.service.AssignRole(audit.NewMeta(c), uint(userId), input.Role); err != nil {
+		if strings.Contains(err.Error(), "Role Not Found") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Role Not Found", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "User Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("User Not Found", nil))
+			return
+		}
+		logrus.Error("Handler : Assign Role Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Assign Role Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Assign Role", nil))
+}
+
+func (r *RoleHandler) RevokeRole(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
+		return
+	}
+
+	if err := r.service.RevokeRole(audit.NewMeta(c), uint(userId), c.Param("role")); err != nil {
+		if strings.Contains(err.Error(), "Role Not Found") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Role Not Found", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Not Assigned") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("Role Not Assigned", nil))
+			return
+		}
+		logrus.Error("Handler : Revoke Role Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Revoke Role Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Revoke Role", nil))
+}
+
+func (r *RoleHandler) SetMFAPolicy(c *gin.Context) {
+	var input = new(MFAPolicyInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	if err := r.service.SetMFARequired(audit.NewMeta(c), c.Param("role"), *input.Required); err != nil {
+		if strings.Contains(err.Error(), "Role Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("Role Not Found", nil))
+			return
+		}
+		logrus.Error("Handler : Set MFA Policy Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Set MFA Policy Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Set MFA Policy", nil))
+}
@@ -0,0 +1,9 @@
+package handler
+
+type MFAPolicyInput struct {
+	Required *bool `json:"required" form:"required" validate:"required"`
+}
+
+type AssignRoleInput struct {
+	Role string `json:"role" form:"role" validate:"required"`
+}
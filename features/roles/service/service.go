@@ -0,0 +1,134 @@
+package service
+
+import (
+	"e-ticketing-gin/features/audit"
+	"e-ticketing-gin/features/roles"
+	"e-ticketing-gin/helper/jwt"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+)
+
+type RoleService struct {
+	data    roles.RoleDataInterface
+	audit   audit.AuditServiceInterface
+	revoker jwt.Revoker
+}
+
+func New(d roles.RoleDataInterface, a audit.AuditServiceInterface, rv jwt.Revoker) *RoleService {
+	return &RoleService{
+		data:    d,
+		audit:   a,
+		revoker: rv,
+	}
+}
+
+func (r *RoleService) GetRoles() ([]roles.Role, error) {
+	res, err := r.data.GetRoles()
+	if err != nil {
+		logrus.Error("Service : Error Get Roles : ", err.Error())
+		return nil, errors.New("ERROR Error Get Roles")
+	}
+
+	return res, nil
+}
+
+func (r *RoleService) GetPermissions() ([]roles.Permission, error) {
+	res, err := r.data.GetPermissions()
+	if err != nil {
+		logrus.Error("Service : Error Get Permissions : ", err.Error())
+		return nil, errors.New("ERROR Error Get Permissions")
+	}
+
+	return res, nil
+}
+
+func (r *RoleService) GetUserAccess(userID uint) (*roles.UserAccess, error) {
+	res, err := r.data.GetUserAccess(userID)
+	if err != nil {
+		logrus.Error("Service : Error Get User Access : ", err.Error())
+		return nil, errors.New("ERROR Error Get User Access")
+	}
+
+	return res, nil
+}
+
+func (r *RoleService) AssignRole(meta audit.Meta, userID uint, role string) error {
+	var before = r.userRoles(userID)
+
+	if err := r.data.AssignRole(userID, role); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return err
+		}
+		logrus.Error("Service : Error Assign Role : ", err.Error())
+		return errors.New("ERROR Error Assign Role")
+	}
+
+	if err := r.revoker.RevokeAccessTokens(userID); err != nil {
+		logrus.Error("Service : Error Revoke Access Tokens : ", err.Error())
+		return errors.New("ERROR Error Assign Role")
+	}
+
+	r.audit.Record(meta, audit.ActionRoleAssign, audit.TargetUser, strconv.Itoa(int(userID)),
+		map[string]any{"roles": before},
+		map[string]any{"roles": r.userRoles(userID), "role": role})
+
+	return nil
+}
+
+func (r *RoleService) SetMFARequired(meta audit.Meta, role string, required bool) error {
+	var before = map[string]any{}
+	if res, err := r.data.GetRoles(); err == nil {
+		for _, val := range res {
+			if val.Name == role {
+				before["mfa_required"] = val.MFARequired
+			}
+		}
+	}
+
+	if err := r.data.SetMFARequired(role, required); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return err
+		}
+		logrus.Error("Service : Error Set MFA Required : ", err.Error())
+		return errors.New("ERROR Error Set MFA Required")
+	}
+
+	r.audit.Record(meta, audit.ActionRoleMFAPolicy, audit.TargetRole, role, before, map[string]any{"mfa_required": required})
+
+	return nil
+}
+
+func (r *RoleService) RevokeRole(meta audit.Meta, userID uint, role string) error {
+	var before = r.userRoles(userID)
+
+	if err := r.data.RevokeRole(userID, role); err != nil {
+		if strings.Contains(err.Error(), "Not Found") || strings.Contains(err.Error(), "Not Assigned") {
+			return err
+		}
+		logrus.Error("Service : Error Revoke Role : ", err.Error())
+		return errors.New("ERROR Error Revoke Role")
+	}
+
+	if err := r.revoker.RevokeAccessTokens(userID); err != nil {
+		logrus.Error("Service : Error Revoke Access Tokens : ", err.Error())
+		return errors.New("ERROR Error Revoke Role")
+	}
+
+	r.audit.Record(meta, audit.ActionRoleRevoke, audit.TargetUser, strconv.Itoa(int(userID)),
+		map[string]any{"roles": before},
+		map[string]any{"roles": r.userRoles(userID), "role": role})
+
+	return nil
+}
+
+func (r *RoleService) userRoles(userID uint) []string {
+	res, err := r.data.GetUserAccess(userID)
+	if err != nil {
+		logrus.Error("Service : Error Get User Roles : ", err.Error())
+		return nil
+	}
+
+	return res.Roles
+}
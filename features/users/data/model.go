@@ -7,23 +7,118 @@ import (
 
 type User struct {
 	*gorm.Model
-	Username    string `gorm:"column:username;type:varchar(255);not null"`
-	Email       string `gorm:"column:email;type:varchar(255);not null"`
-	PhoneNumber string `gorm:"column:phone_number;type:varchar(255);not null"`
-	Password    string `gorm:"column:password;type:varchar(255);not null"`
-	IsAdmin     bool   `gorm:"column:is_admin;type:bool;not null"`
-	Status      bool   `gorm:"column:status;type:bool;not null"`
+	Username    string     `gorm:"column:username;type:varchar(255);not null;uniqueIndex:idx_users_username_lower,expression:lower(username)"`
+	Email       string     `gorm:"column:email;type:varchar(255);not null;uniqueIndex:idx_users_email_lower,expression:lower(email)"`
+	PhoneNumber string     `gorm:"column:phone_number;type:varchar(255);not null"`
+	Password    string     `gorm:"column:password;type:varchar(255);not null"`
+	Status      bool       `gorm:"column:status;type:bool;not null"`
+	Language    string     `gorm:"column:language;type:varchar(5);not null;default:id"`
+	PurgedAt    *time.Time `gorm:"column:purged_at;type:timestamp"`
+	VerifiedAt  *time.Time `gorm:"column:verified_at;type:timestamptz;index"`
+	ActivatedAt *time.Time `gorm:"column:activated_at;type:timestamptz;index"`
+	AvatarURL   string     `gorm:"column:avatar_url;type:varchar(1024);not null;default:''"`
+	AvatarKey   string     `gorm:"column:avatar_key;type:varchar(255);not null;default:''"`
+}
+
+type UserPasswordHistory struct {
+	*gorm.Model
+	UserID   uint   `gorm:"column:user_id;index;not null"`
+	Password string `gorm:"column:password;type:varchar(255);not null"`
 }
 
 type UserResetPass struct {
 	*gorm.Model
-	Username  string    `gorm:"column:username;type:varchar(255);not null"`
-	Code      string    `gorm:"column:code;type:varchar(255);not null"`
+	Username  string    `gorm:"column:username;type:varchar(255);index;not null"`
+	CodeHash  string    `gorm:"column:code_hash;type:varchar(64);not null"`
+	Attempts  int       `gorm:"column:attempts;not null;default:0"`
+	ExpiredAt time.Time `gorm:"column:expired_at;type:timestamp;not null"`
+}
+
+type UserContactChange struct {
+	*gorm.Model
+	UserID    uint      `gorm:"column:user_id;uniqueIndex:idx_contact_change_user_kind;not null"`
+	Kind      string    `gorm:"column:kind;type:varchar(10);uniqueIndex:idx_contact_change_user_kind;not null"`
+	Value     string    `gorm:"column:value;type:varchar(255);not null"`
+	CodeHash  string    `gorm:"column:code_hash;type:varchar(64);not null"`
+	Attempts  int       `gorm:"column:attempts;not null;default:0"`
 	ExpiredAt time.Time `gorm:"column:expired_at;type:timestamp;not null"`
 }
 
+type UserRefreshToken struct {
+	*gorm.Model
+	UserID    uint       `gorm:"column:user_id;index;not null"`
+	FamilyID  string     `gorm:"column:family_id;type:varchar(64);index;not null"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(64);uniqueIndex;not null"`
+	Device    string     `gorm:"column:device;type:varchar(255)"`
+	ExpiredAt time.Time  `gorm:"column:expired_at;type:timestamp;not null"`
+	RotatedAt *time.Time `gorm:"column:rotated_at;type:timestamp"`
+	RevokedAt *time.Time `gorm:"column:revoked_at;type:timestamp"`
+}
+
+type UserSession struct {
+	*gorm.Model
+	UserID     uint       `gorm:"column:user_id;index;not null"`
+	FamilyID   string     `gorm:"column:family_id;type:varchar(64);uniqueIndex;not null"`
+	Device     string     `gorm:"column:device;type:varchar(255)"`
+	UserAgent  string     `gorm:"column:user_agent;type:varchar(512)"`
+	IPAddress  string     `gorm:"column:ip_address;type:varchar(64)"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at;type:timestamp;not null"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at;type:timestamp"`
+}
+
+type RevokedToken struct {
+	*gorm.Model
+	JTI           string     `gorm:"column:jti;type:varchar(64);index"`
+	SessionID     string     `gorm:"column:session_id;type:varchar(64);index"`
+	UserID        uint       `gorm:"column:user_id;index;not null"`
+	RevokedBefore *time.Time `gorm:"column:revoked_before;type:timestamp"`
+	ExpiredAt     time.Time  `gorm:"column:expired_at;type:timestamp;index;not null"`
+}
+
+type UserMFA struct {
+	*gorm.Model
+	UserID         uint       `gorm:"column:user_id;uniqueIndex;not null"`
+	Secret         string     `gorm:"column:secret;type:varchar(255);not null"`
+	ConfirmedAt    *time.Time `gorm:"column:confirmed_at;type:timestamp"`
+	LastUsedStep   int64      `gorm:"column:last_used_step;not null;default:0"`
+	FailedAttempts int        `gorm:"column:failed_attempts;not null;default:0"`
+	LockedUntil    *time.Time `gorm:"column:locked_until;type:timestamp"`
+}
+
+type UserRecoveryCode struct {
+	*gorm.Model
+	UserID   uint       `gorm:"column:user_id;index;not null"`
+	CodeHash string     `gorm:"column:code_hash;type:varchar(64);not null"`
+	UsedAt   *time.Time `gorm:"column:used_at;type:timestamp"`
+}
+
+type LoginAttempt struct {
+	*gorm.Model
+	Kind         string     `gorm:"column:kind;type:varchar(20);uniqueIndex:idx_login_attempt_kind_key;not null"`
+	Key          string     `gorm:"column:key;type:varchar(255);uniqueIndex:idx_login_attempt_kind_key;not null"`
+	FailedCount  int        `gorm:"column:failed_count;not null;default:0"`
+	LastFailedAt *time.Time `gorm:"column:last_failed_at;type:timestamp"`
+	LockedUntil  *time.Time `gorm:"column:locked_until;type:timestamp"`
+}
+
+type LockoutEvent struct {
+	*gorm.Model
+	UserID    uint   `gorm:"column:user_id;index"`
+	Username  string `gorm:"column:username;type:varchar(255);index;not null"`
+	IPAddress string `gorm:"column:ip_address;type:varchar(64)"`
+	Event     string `gorm:"column:event;type:varchar(20);not null"`
+	Reason    string `gorm:"column:reason;type:varchar(255)"`
+	ActorID   uint   `gorm:"column:actor_id"`
+}
+
+type VerificationRequest struct {
+	*gorm.Model
+	Username string `gorm:"column:username;type:varchar(255);index;not null"`
+}
+
 type UserVerification struct {
-	Username  string    `gorm:"column:username;type:varchar(255);not null"`
-	Code      string    `gorm:"column:code;type:varchar(255);not null"`
+	Username  string    `gorm:"column:username;type:varchar(255);index;not null"`
+	CodeHash  string    `gorm:"column:code_hash;type:varchar(64);not null"`
+	Attempts  int       `gorm:"column:attempts;not null;default:0"`
 	ExpiredAt time.Time `gorm:"column:expired_at;type:timestamp;not null"`
 }
@@ -1,17 +1,32 @@
 package data
 
 import (
+	"crypto/rand"
+	"database/sql"
+	"e-ticketing-gin/features/outbox"
+	outboxData "e-ticketing-gin/features/outbox/data"
+	roleData "e-ticketing-gin/features/roles/data"
 	"e-ticketing-gin/features/users"
 	"e-ticketing-gin/helper/enkrip"
+	"e-ticketing-gin/helper/jwt"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 type UserData struct {
-	db     *gorm.DB
-	enkrip enkrip.HashInterface
+	db        *gorm.DB
+	enkrip    enkrip.HashInterface
+	dummyOnce sync.Once
+	dummyHash string
 }
 
 func New(db *gorm.DB, e enkrip.HashInterface) *UserData {
@@ -21,32 +36,60 @@ func New(db *gorm.DB, e enkrip.HashInterface) *UserData {
 	}
 }
 
-func (ud *UserData) Register(newData users.User) (*users.User, error) {
+func (ud *UserData) Register(newData users.User, role string, verification users.UserVerification, mail outbox.Message) (*users.User, error) {
 	var dbData = new(User)
 	dbData.Username = newData.Username
 	dbData.Email = newData.Email
 	dbData.PhoneNumber = newData.PhoneNumber
 	dbData.Password = newData.Password
-	dbData.IsAdmin = newData.IsAdmin
 	dbData.Status = newData.Status
+	dbData.Language = newData.Language
 
-	if err := ud.db.Create(dbData).Error; err != nil {
-		logrus.Error("DATA : Register Error : ", err.Error())
+	err := ud.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dbData).Error; err != nil {
+			logrus.Error("DATA : Register Error : ", err.Error())
+			return identityConflict(err)
+		}
+
+		if err := roleData.Assign(tx, dbData.ID, role); err != nil {
+			return err
+		}
+
+		return insertVerificationCode(tx, verification.Username, verification.CodeHash, verification.ExpiredAt, mail)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	newData.ID = dbData.ID
 	return &newData, nil
 }
 
+func identityConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		switch pgErr.ConstraintName {
+		case "idx_users_username_lower":
+			return errors.New("ERROR Username already registered")
+		case "idx_users_email_lower":
+			return errors.New("ERROR Email already registered")
+		}
+	}
+	return err
+}
+
 func (ud *UserData) Login(username, password string) (*users.User, error) {
 	var dbdata = new(User)
 	var dataCount int64
 	dbdata.Username = username
 
-	var qry = ud.db.Where("username = ? AND status = ?", username, true).First(dbdata)
+	var qry = ud.db.Where("(LOWER(username) = LOWER(?) OR LOWER(email) = LOWER(?)) AND status = ?", username, username, true).
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "LOWER(username) = LOWER(?) DESC, id ASC", Vars: []any{username}}}).
+		Take(dbdata)
 	qry.Count(&dataCount)
 
 	if dataCount == 0 {
+		ud.compareDummy(password)
 		logrus.Error("DATA : Login Error : Data Not Found")
 		return nil, errors.New("ERROR Data Not Found")
 	}
@@ -57,24 +100,55 @@ func (ud *UserData) Login(username, password string) (*users.User, error) {
 	}
 
 	if err := ud.enkrip.Compare(dbdata.Password, password); err != nil {
+		if !errors.Is(err, enkrip.ErrMismatch) {
+			logrus.Error("DATA : Compare Password Error : ", err.Error())
+			return nil, err
+		}
 		logrus.Error("DATA : Incorrect Password")
 		return nil, errors.New("ERROR Incorrect Password")
 	}
 
+	if ud.enkrip.NeedsRehash(dbdata.Password) {
+		ud.rehash(dbdata.ID, password)
+	}
+
 	var result = new(users.User)
 	result.ID = dbdata.ID
 	result.Username = dbdata.Username
 	result.Email = dbdata.Email
 	result.PhoneNumber = dbdata.PhoneNumber
-	result.IsAdmin = dbdata.IsAdmin
 	result.Status = dbdata.Status
 
 	return result, nil
 }
 
+func (ud *UserData) rehash(id uint, password string) {
+	hashPassword, err := ud.enkrip.HashPassword(password)
+	if err != nil {
+		logrus.Error("DATA : Rehash Password Error : ", err.Error())
+		return
+	}
+
+	if err := ud.db.Model(&User{}).Where("id = ?", id).Update("password", hashPassword).Error; err != nil {
+		logrus.Error("DATA : Update Rehashed Password Error : ", err.Error())
+	}
+}
+
+func (ud *UserData) compareDummy(password string) {
+	ud.dummyOnce.Do(func() {
+		hashPassword, err := ud.enkrip.HashPassword("dummy-password-for-timing")
+		if err != nil {
+			logrus.Error("DATA : Hash Dummy Password Error : ", err.Error())
+			return
+		}
+		ud.dummyHash = hashPassword
+	})
+	_ = ud.enkrip.Compare(ud.dummyHash, password)
+}
+
 func (ud *UserData) GetByID(id int) (users.User, error) {
 	var listUser users.User
-	var qry = ud.db.Where("id = ? ", id).Where("status = ?", true).First(&listUser)
+	var qry = ud.db.Model(&User{}).Where("id = ? ", id).Where("status = ?", true).First(&listUser)
 
 	if err := qry.Error; err != nil {
 		logrus.Error("DATA : Error Get By ID : ", err.Error())
@@ -84,10 +158,33 @@ func (ud *UserData) GetByID(id int) (users.User, error) {
 	return listUser, nil
 }
 
+func (ud *UserData) GetAccountByID(id int) (*users.User, error) {
+	var dbData = new(User)
+	var qry = ud.db.Where("id = ?", id).Take(dbData)
+
+	if err := qry.Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ERROR User Not Found")
+		}
+		logrus.Error("DATA : Error Get Account By ID : ", err.Error())
+		return nil, err
+	}
+
+	var result = new(users.User)
+	result.ID = dbData.ID
+	result.Username = dbData.Username
+	result.Email = dbData.Email
+	result.PhoneNumber = dbData.PhoneNumber
+	result.Status = dbData.Status
+	result.Language = dbData.Language
+
+	return result, nil
+}
+
 func (ud *UserData) GetByUsername(username string) (*users.User, error) {
 	var dbData = new(User)
 	dbData.Username = username
-	var qry = ud.db.Where("username = ? ", dbData.Username).Where("status = ?", true).First(dbData)
+	var qry = ud.db.Where("LOWER(username) = LOWER(?)", dbData.Username).Where("status = ?", true).First(dbData)
 
 	if err := qry.Error; err != nil {
 		logrus.Error("DATA : Error Get By ID : ", err.Error())
@@ -99,15 +196,109 @@ func (ud *UserData) GetByUsername(username string) (*users.User, error) {
 	result.Username = dbData.Username
 	result.Email = dbData.Email
 	result.PhoneNumber = dbData.PhoneNumber
-	result.IsAdmin = dbData.IsAdmin
 	result.Status = dbData.Status
+	result.Language = dbData.Language
+
+	return result, nil
+}
+
+func (ud *UserData) GetByIdentifier(identifier string) (*users.User, error) {
+	var dbData = new(User)
+	var qry = ud.db.Where("LOWER(username) = LOWER(?) OR LOWER(email) = LOWER(?)", identifier, identifier).
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "LOWER(username) = LOWER(?) DESC, id ASC", Vars: []any{identifier}}}).
+		Take(dbData)
+
+	if err := qry.Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ERROR User Not Found")
+		}
+		logrus.Error("DATA : Error Get By Identifier : ", err.Error())
+		return nil, err
+	}
+
+	var result = new(users.User)
+	result.ID = dbData.ID
+	result.Username = dbData.Username
+	result.Email = dbData.Email
+	result.PhoneNumber = dbData.PhoneNumber
+	result.Status = dbData.Status
+	result.Language = dbData.Language
+
+	return result, nil
+}
+
+func (ud *UserData) GetUnverifiedUser(identifier string) (*users.User, error) {
+	var dbData = new(User)
+	var qry = ud.db.Where("(LOWER(username) = LOWER(?) OR LOWER(email) = LOWER(?)) AND verified_at IS NULL AND activated_at IS NULL", identifier, identifier).First(dbData)
+
+	if err := qry.Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ERROR User Not Found")
+		}
+		logrus.Error("DATA : Error Get Unverified User : ", err.Error())
+		return nil, err
+	}
+
+	var result = new(users.User)
+	result.ID = dbData.ID
+	result.Username = dbData.Username
+	result.Email = dbData.Email
+	result.PhoneNumber = dbData.PhoneNumber
+	result.Status = dbData.Status
+	result.Language = dbData.Language
+
+	return result, nil
+}
+
+func (ud *UserData) GetVerificationRequests(username string, since time.Time) ([]time.Time, error) {
+	var result []time.Time
+
+	var qry = ud.db.Model(&VerificationRequest{}).
+		Where("LOWER(username) = LOWER(?) AND created_at >= ?", username, since).
+		Order("created_at DESC").
+		Pluck("created_at", &result)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Get Verification Requests Error : ", err.Error())
+		return nil, err
+	}
 
 	return result, nil
 }
 
+func insertVerificationCode(tx *gorm.DB, username, codeHash string, expiredAt time.Time, mail outbox.Message) error {
+	if err := tx.Where("LOWER(username) = LOWER(?)", username).Delete(&UserVerification{}).Error; err != nil {
+		logrus.Error("DATA : Delete Code Verification Error : ", err.Error())
+		return err
+	}
+
+	var code = new(UserVerification)
+	code.Username = username
+	code.CodeHash = codeHash
+	code.ExpiredAt = expiredAt
+
+	if err := tx.Create(code).Error; err != nil {
+		logrus.Error("DATA : Insert Code Verification Error : ", err.Error())
+		return err
+	}
+
+	var qry = tx.Unscoped().Where("LOWER(username) = LOWER(?) AND created_at < ?", username, time.Now().Add(-time.Hour*24)).Delete(&VerificationRequest{})
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Delete Verification Requests Error : ", err.Error())
+		return err
+	}
+
+	if err := tx.Create(&VerificationRequest{Username: username}).Error; err != nil {
+		logrus.Error("DATA : Insert Verification Request Error : ", err.Error())
+		return err
+	}
+
+	return outboxData.Enqueue(tx, mail)
+}
+
 func (ud *UserData) CheckUsername(username string) bool {
 	var count int64
-	var qry = ud.db.Table("users").Where("username = ? ", username).Count(&count)
+	var qry = ud.db.Table("users").Where("LOWER(username) = LOWER(@identity) OR LOWER(email) = LOWER(@identity)", sql.Named("identity", strings.TrimSpace(username))).Count(&count)
 
 	if err := qry.Error; err != nil {
 		logrus.Error("DATA : Check Username Error : ", err.Error())
@@ -121,223 +312,1459 @@ func (ud *UserData) CheckUsername(username string) bool {
 	return false
 }
 
-func (ud *UserData) InsertCodeReset(username, code string) error {
+func (ud *UserData) InsertCodeReset(username, codeHash string, expiredAt time.Time, mail outbox.Message) error {
 	var newData = new(UserResetPass)
 	newData.Username = username
-	newData.Code = code
-	newData.ExpiredAt = time.Now().Add(time.Minute * 10)
+	newData.CodeHash = codeHash
+	newData.ExpiredAt = expiredAt
 
-	_, err := ud.GetByCodeReset(code)
-	if err != nil {
-		errDelete := ud.DeleteCodeReset(code)
-		if errDelete != nil {
-			return errors.New("ERROR Delete CodeReset Error : " + errDelete.Error())
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("LOWER(username) = LOWER(?)", username).Delete(&UserResetPass{}).Error; err != nil {
+			logrus.Error("DATA : Delete Code Reset Pass Error : ", err.Error())
+			return err
 		}
-	}
 
-	if err := ud.db.Table("user_reset_passes").Create(newData).Error; err != nil {
-		logrus.Error("DATA : Insert Code Reset Pass Error : ", err.Error())
-		return err
-	}
+		if err := tx.Create(newData).Error; err != nil {
+			logrus.Error("DATA : Insert Code Reset Pass Error : ", err.Error())
+			return err
+		}
 
-	return nil
+		return outboxData.Enqueue(tx, mail)
+	})
 }
 
-func (ud *UserData) DeleteCodeReset(code string) error {
-	var deleteData = new(UserResetPass)
-
-	if err := ud.db.Table("user_reset_passes").Where("code = ?", code).Delete(deleteData).Error; err != nil {
-		logrus.Error("DATA : Delete Code Reset Pass Error : ", err.Error())
-		return err
-	}
-
-	return nil
-}
+func (ud *UserData) TakeCodeResetAttempt(username string, maxAttempts int) (*users.UserResetPass, error) {
+	var dbData []UserResetPass
 
-func (ud *UserData) GetByCodeReset(code string) (*users.UserResetPass, error) {
-	var dbData = new(UserResetPass)
-	dbData.Code = code
+	var qry = ud.db.Model(&dbData).
+		Clauses(clause.Returning{}).
+		Where("LOWER(username) = LOWER(?) AND attempts < ? AND expired_at > ?", username, maxAttempts, time.Now()).
+		Update("attempts", gorm.Expr("attempts + 1"))
 
-	if err := ud.db.Table("user_reset_passes").Where("code = ?", dbData.Code).First(&dbData).Error; err != nil {
-		logrus.Error("DATA : Get By Code Reset Pass Error : ", err.Error())
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Take Code Reset Attempt Error : ", err.Error())
 		return nil, err
 	}
 
+	if len(dbData) == 0 {
+		return nil, errors.New("ERROR Code Not Found")
+	}
+
 	var result = new(users.UserResetPass)
-	result.Code = dbData.Code
-	result.ExpiredAt = dbData.ExpiredAt
-	result.Username = dbData.Username
+	result.Username = dbData[0].Username
+	result.CodeHash = dbData[0].CodeHash
+	result.Attempts = dbData[0].Attempts
+	result.ExpiredAt = dbData[0].ExpiredAt
 
 	return result, nil
 }
-func (ud *UserData) ResetPassword(code, username, password string) error {
-	if err := ud.db.Where("username = ?", username).Update("password", password).Error; err != nil {
-		logrus.Error("DATA : Reset Password Error : ", err.Error())
+
+func (ud *UserData) ResetPassword(username, codeHash, password string, keep int) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		var qry = tx.Unscoped().Where("LOWER(username) = LOWER(?) AND code_hash = ?", username, codeHash).Delete(&UserResetPass{})
+		if err := qry.Error; err != nil {
+			logrus.Error("DATA : Delete Code Reset Error : ", err.Error())
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("ERROR Code Not Found")
+		}
+
+		var user = new(User)
+		if err := tx.Where("LOWER(username) = LOWER(?)", username).First(user).Error; err != nil {
+			logrus.Error("DATA : Reset Password Error : ", err.Error())
+			return err
+		}
+
+		return replacePassword(tx, user.ID, user.Password, password, keep)
+	})
+}
+
+func replacePassword(tx *gorm.DB, userID uint, oldPassword, password string, keep int) error {
+	var history = new(UserPasswordHistory)
+	history.UserID = userID
+	history.Password = oldPassword
+
+	if err := tx.Create(history).Error; err != nil {
+		logrus.Error("DATA : Insert Password History Error : ", err.Error())
 		return err
 	}
 
-	checkData, err := ud.GetByCodeReset(code)
-	if err != nil {
-		logrus.Error("DATA : Get By Code Reset Error : ", err.Error())
+	if err := tx.Model(&User{}).Where("id = ?", userID).Update("password", password).Error; err != nil {
+		logrus.Error("DATA : Update Password Error : ", err.Error())
 		return err
 	}
 
-	if checkData.Code != "" {
-		err := ud.DeleteCodeReset(code)
-		if err != nil {
-			logrus.Error("DATA : Delete Code Reset Error : ", err.Error())
-			return err
-		}
+	var recent = tx.Model(&UserPasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(keep)
+
+	var qry = tx.Unscoped().Where("user_id = ? AND id NOT IN (?)", userID, recent).Delete(&UserPasswordHistory{})
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Prune Password History Error : ", err.Error())
+		return err
 	}
 
 	return nil
 }
 
-func (ud *UserData) UpdateProfile(id int, newData users.UpdateProfile) (bool, error) {
-	var qry = ud.db.Where("id = ? ", id).Updates(User{
-		Username:    newData.Username,
-		Email:       newData.Email,
-		PhoneNumber: newData.PhoneNumber,
+func (ud *UserData) ChangePassword(userID uint, oldPassword, password string, keep int) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		return replacePassword(tx, userID, oldPassword, password, keep)
 	})
+}
 
-	if err := qry.Error; err != nil {
-		logrus.Error("DATA : Error Update Profile : ", err.Error())
-		return false, err
-	}
+func (ud *UserData) GetPasswordHistory(userID uint, limit int) ([]string, error) {
+	var result []string
 
-	if datacount := qry.RowsAffected; datacount < 1 {
-		logrus.Error("DATA : Update Profile Error : No Row Affected")
-		return false, errors.New("ERROR Update Profile Error : No Row Affected")
+	var qry = ud.db.Model(&UserPasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Pluck("password", &result)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Get Password History Error : ", err.Error())
+		return nil, err
 	}
 
-	return true, nil
+	return result, nil
 }
 
-func (ud *UserData) GetAll() ([]users.User, error) {
-	var listUser []users.User
+func (ud *UserData) UpdateAvatar(userID uint, url, key string) (string, error) {
+	var previous string
 
-	if err := ud.db.Find(&listUser).Error; err != nil {
-		return nil, err
+	var err = ud.db.Transaction(func(tx *gorm.DB) error {
+		var user = new(User)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "avatar_key").Where("id = ? AND status = ?", userID, true).First(user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("ERROR User Not Found")
+			}
+			return err
+		}
+		previous = user.AvatarKey
+
+		var qry = tx.Model(&User{}).Where("id = ?", userID).UpdateColumns(map[string]any{
+			"avatar_url": url,
+			"avatar_key": key,
+			"updated_at": time.Now(),
+		})
+		return qry.Error
+	})
+
+	if err != nil {
+		logrus.Error("DATA : Update Avatar Error : ", err.Error())
+		return "", err
 	}
 
-	return listUser, nil
+	return previous, nil
 }
 
-func (ud *UserData) Activate(id int) (bool, error) {
-	var qry = ud.db.Where("id = ?", id).Updates(User{Status: true})
+func (ud *UserData) UpdateProfile(id int, newData users.UpdateProfile, changes []users.ContactChange, mails []outbox.Message) (bool, error) {
+	var err = ud.db.Transaction(func(tx *gorm.DB) error {
+		if newData != (users.UpdateProfile{}) {
+			var qry = tx.Where("id = ? ", id).Updates(User{
+				Username:    newData.Username,
+				Email:       newData.Email,
+				PhoneNumber: newData.PhoneNumber,
+				Language:    newData.Language,
+			})
+
+			if err := qry.Error; err != nil {
+				logrus.Error("DATA : Error Update Profile : ", err.Error())
+				return identityConflict(err)
+			}
+
+			if datacount := qry.RowsAffected; datacount < 1 {
+				logrus.Error("DATA : Update Profile Error : No Row Affected")
+				return errors.New("ERROR Update Profile Error : No Row Affected")
+			}
+		}
 
-	if err := qry.Error; err != nil {
+		for _, change := range changes {
+			if err := tx.Unscoped().Where("user_id = ? AND kind = ?", change.UserID, change.Kind).Delete(&UserContactChange{}).Error; err != nil {
+				logrus.Error("DATA : Delete Contact Change Error : ", err.Error())
+				return err
+			}
+
+			var newChange = new(UserContactChange)
+			newChange.UserID = change.UserID
+			newChange.Kind = change.Kind
+			newChange.Value = change.Value
+			newChange.CodeHash = change.CodeHash
+			newChange.ExpiredAt = change.ExpiredAt
+
+			if err := tx.Create(newChange).Error; err != nil {
+				logrus.Error("DATA : Insert Contact Change Error : ", err.Error())
+				return err
+			}
+		}
+
+		for _, mail := range mails {
+			if err := outboxData.Enqueue(tx, mail); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
 
-func (ud *UserData) Deactivate(id int) (bool, error) {
-	var qry = ud.db.Model(User{}).Where("id = ?", id).Updates(map[string]interface{}{"status": false})
+func (ud *UserData) CheckEmail(email string) bool {
+	var count int64
+	var qry = ud.db.Table("users").Where("LOWER(email) = LOWER(@identity) OR LOWER(username) = LOWER(@identity)", sql.Named("identity", strings.TrimSpace(email))).Count(&count)
 
 	if err := qry.Error; err != nil {
-		return false, err
+		logrus.Error("DATA : Check Email Error : ", err.Error())
+		return false
 	}
 
-	return true, nil
+	if count == 0 {
+		return true
+	}
+
+	return false
 }
 
-func (ud *UserData) UserDashboard() (users.UserDashboard, error) {
-	var dashboardUser users.UserDashboard
+func (ud *UserData) TakeContactChangeAttempt(userID uint, kind string, maxAttempts int) (*users.ContactChange, error) {
+	var dbData []UserContactChange
+
+	var qry = ud.db.Model(&dbData).
+		Clauses(clause.Returning{}).
+		Where("user_id = ? AND kind = ? AND attempts < ? AND expired_at > ?", userID, kind, maxAttempts, time.Now()).
+		Update("attempts", gorm.Expr("attempts + 1"))
 
-	tUser, tNewUser, tUserActive, tUserInactive := ud.getTotalUser()
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Take Contact Change Attempt Error : ", err.Error())
+		return nil, err
+	}
+
+	if len(dbData) == 0 {
+		return nil, errors.New("ERROR Code Not Found")
+	}
 
-	dashboardUser.TotalUser = tUser
-	dashboardUser.TotalNewUser = tNewUser
-	dashboardUser.TotalUserActive = tUserActive
-	dashboardUser.TotalUserInactive = tUserInactive
+	var result = new(users.ContactChange)
+	result.UserID = dbData[0].UserID
+	result.Kind = dbData[0].Kind
+	result.Value = dbData[0].Value
+	result.CodeHash = dbData[0].CodeHash
+	result.Attempts = dbData[0].Attempts
+	result.ExpiredAt = dbData[0].ExpiredAt
 
-	return dashboardUser, nil
+	return result, nil
 }
 
-func (ud *UserData) getTotalUser() (int, int, int, int) {
-	var totalUser int64
-	var totalNewUser int64
-	var totalUserActive int64
-	var totalUserInactive int64
+func (ud *UserData) ApplyContactChange(userID uint, kind, codeHash string) error {
+	var column string
+	switch kind {
+	case users.ContactKindEmail:
+		column = "email"
+	case users.ContactKindPhone:
+		column = "phone_number"
+	default:
+		return errors.New("ERROR Code Not Found")
+	}
 
-	var now = time.Now()
-	var before = now.AddDate(0, 0, -7)
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		var dbData []UserContactChange
+		var qry = tx.Unscoped().Clauses(clause.Returning{}).Where("user_id = ? AND kind = ? AND code_hash = ?", userID, kind, codeHash).Delete(&dbData)
+		if err := qry.Error; err != nil {
+			logrus.Error("DATA : Delete Contact Change Error : ", err.Error())
+			return err
+		}
+
+		if len(dbData) == 0 {
+			return errors.New("ERROR Code Not Found")
+		}
 
-	var _ = ud.db.Count(&totalUser)
-	var _ = ud.db.Where("created_at BETWEEN ? AND ?", before, now).Count(&totalNewUser)
-	var _ = ud.db.Where("status = ?", true).Count(&totalUserActive)
-	var _ = ud.db.Where("status = ?", false).Count(&totalUserInactive)
+		if kind == users.ContactKindEmail {
+			var count int64
+			if err := tx.Model(&User{}).Where("(LOWER(email) = LOWER(?) OR LOWER(username) = LOWER(?)) AND id <> ?", dbData[0].Value, dbData[0].Value, userID).Count(&count).Error; err != nil {
+				logrus.Error("DATA : Check Email Error : ", err.Error())
+				return err
+			}
 
-	totalUserInt := int(totalUser)
-	totalNewUserInt := int(totalNewUser)
-	totalUserActiveInt := int(totalUserActive)
-	totalUserInactiveInt := int(totalUserInactive)
+			if count > 0 {
+				return errors.New("ERROR Email already registered")
+			}
+		}
+
+		if err := tx.Model(&User{}).Where("id = ?", userID).Update(column, dbData[0].Value).Error; err != nil {
+			logrus.Error("DATA : Apply Contact Change Error : ", err.Error())
+			return identityConflict(err)
+		}
 
-	return totalUserInt, totalNewUserInt, totalUserActiveInt, totalUserInactiveInt
+		return nil
+	})
 }
 
-func (ud *UserData) InsertCodeVerification(username, code string) error {
-	var newData = new(UserVerification)
-	newData.Username = username
-	newData.Code = code
-	newData.ExpiredAt = time.Now().Add(time.Minute * 10)
+const exportBatchSize = 500
 
-	_, err := ud.GetByCodeVerification(code)
-	if err != nil {
-		errDel := ud.DeleteCodeVerification(code)
-		if errDel != nil {
-			logrus.Error("DATA : Delete Code Verification Error : ", err.Error())
-			return err
+var userSortColumns = map[string]string{
+	"id":         "users.id",
+	"username":   "users.username",
+	"email":      "users.email",
+	"created_at": "users.created_at",
+}
+
+func (ud *UserData) usersBase(query users.UserQuery) *gorm.DB {
+	var filter = func(db *gorm.DB) *gorm.DB {
+		if query.Search != "" {
+			var pattern = "%" + escapeLike(strings.ToLower(query.Search)) + "%"
+			db = db.Where("(LOWER(users.username) LIKE ? OR LOWER(users.email) LIKE ? OR users.phone_number LIKE ?)", pattern, pattern, pattern)
+		}
+		if query.Status != nil {
+			db = db.Where("users.status = ?", *query.Status)
+		}
+		if query.Role != "" {
+			db = db.Where("users.id IN (?)", ud.db.Table("user_roles").
+				Select("user_roles.user_id").
+				Joins("JOIN roles ON roles.id = user_roles.role_id").
+				Where("roles.name = ?", query.Role))
 		}
+		if query.CreatedFrom != nil {
+			db = db.Where("users.created_at >= ?", *query.CreatedFrom)
+		}
+		if query.CreatedTo != nil {
+			db = db.Where("users.created_at < ?", *query.CreatedTo)
+		}
+		return db
 	}
 
-	if err := ud.db.Table("user_verifications").Create(newData).Error; err != nil {
-		logrus.Error("DATA : Insert Code Verification Error : ", err.Error())
-		return err
+	if query.Deleted {
+		return ud.db.Unscoped().Model(&User{}).Where("users.deleted_at IS NOT NULL").Scopes(filter)
 	}
-
-	return nil
+	return ud.db.Model(&User{}).Scopes(filter)
 }
-func (ud *UserData) DeleteCodeVerification(code string) error {
-	var deleteData = new(UserVerification)
 
-	if err := ud.db.Table("user_verifications").Where("code = ?", code).Delete(deleteData).Error; err != nil {
-		logrus.Error("DATA : Delete Code Verification Error : ", err.Error())
-		return err
+func (ud *UserData) GetUsers(query users.UserQuery) (*users.UserPage, error) {
+	var result = new(users.UserPage)
+
+	if err := ud.usersBase(query).Count(&result.Total).Error; err != nil {
+		logrus.Error("DATA : Count Users Error : ", err.Error())
+		return nil, err
 	}
 
-	return nil
-}
-func (ud *UserData) GetByCodeVerification(code string) (*users.UserVerification, error) {
-	var dbData = new(UserVerification)
-	dbData.Code = code
+	var column, found = userSortColumns[query.Sort]
+	if !found {
+		column = userSortColumns["id"]
+	}
+
+	var direction, operator = "ASC", ">"
+	if strings.EqualFold(query.Order, "desc") {
+		direction, operator = "DESC", "<"
+	}
+
+	var qry = ud.usersBase(query)
 
-	if err := ud.db.Table("user_verifications").Where("code = ?", code).First(&dbData).Error; err != nil {
-		logrus.Error("DATA : Get By Code Verification Error : ", err.Error())
+	if query.Cursor != "" {
+		value, id, err := decodeUserCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		if column == userSortColumns["id"] {
+			qry = qry.Where("users.id "+operator+" ?", id)
+		} else {
+			qry = qry.Where("("+column+", users.id) "+operator+" (?, ?)", value, id)
+		}
+	} else if query.Page > 1 {
+		qry = qry.Offset((query.Page - 1) * query.Limit)
+	}
+
+	var dbData []User
+	if err := qry.Order(column + " " + direction + ", users.id " + direction).Limit(query.Limit + 1).Find(&dbData).Error; err != nil {
+		logrus.Error("DATA : Get Users Error : ", err.Error())
 		return nil, err
 	}
 
-	var result = new(users.UserVerification)
-	result.Code = dbData.Code
-	result.ExpiredAt = dbData.ExpiredAt
-	result.Username = dbData.Username
+	if len(dbData) > query.Limit {
+		dbData = dbData[:query.Limit]
+		result.NextCursor = encodeUserCursor(dbData[len(dbData)-1], query.Sort)
+	}
+
+	roles, err := ud.getUsersRoles(dbData)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Users = []users.UserSummary{}
+	for _, val := range dbData {
+		result.Users = append(result.Users, userToSummary(val, roles[val.ID]))
+	}
 
 	return result, nil
 }
-func (ud *UserData) UserVerification(code, username string) error {
-	if err := ud.db.Where("username = ?", username).Update("status", true).Error; err != nil {
-		logrus.Error("DATA : Update User Verification Error : ", err.Error())
+
+func (ud *UserData) ExportUsers(query users.UserQuery, fn func([]users.UserSummary) error) error {
+	var dbData []User
+
+	var qry = ud.usersBase(query).FindInBatches(&dbData, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		roles, err := ud.getUsersRoles(dbData)
+		if err != nil {
+			return err
+		}
+
+		var summaries []users.UserSummary
+		for _, val := range dbData {
+			summaries = append(summaries, userToSummary(val, roles[val.ID]))
+		}
+		return fn(summaries)
+	})
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Export Users Error : ", err.Error())
 		return err
 	}
 
-	checkData, _ := ud.GetByCodeVerification(code)
-	if checkData.Code != "" {
-		errDel := ud.DeleteCodeVerification(code)
-		if errDel != nil {
-			logrus.Error("DATA : Delete Code Verification Error : ", errDel.Error())
-		}
+	return nil
+}
+
+func (ud *UserData) ImportUser(newData users.User, role string, reset *users.UserResetPass, mail *outbox.Message) (*users.User, error) {
+	var now = time.Now()
+
+	var dbData = new(User)
+	dbData.Username = newData.Username
+	dbData.Email = newData.Email
+	dbData.PhoneNumber = newData.PhoneNumber
+	dbData.Password = newData.Password
+	dbData.Status = true
+	dbData.Language = newData.Language
+	dbData.VerifiedAt = &now
+	dbData.ActivatedAt = &now
+
+	err := ud.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dbData).Error; err != nil {
+			logrus.Error("DATA : Import User Error : ", err.Error())
+			return identityConflict(err)
+		}
+
+		if err := roleData.Assign(tx, dbData.ID, role); err != nil {
+			return err
+		}
+
+		if reset == nil {
+			return nil
+		}
+
+		var code = new(UserResetPass)
+		code.Username = dbData.Username
+		code.CodeHash = reset.CodeHash
+		code.ExpiredAt = reset.ExpiredAt
+
+		if err := tx.Create(code).Error; err != nil {
+			logrus.Error("DATA : Insert Invitation Code Error : ", err.Error())
+			return err
+		}
+
+		return outboxData.Enqueue(tx, *mail)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	newData.ID = dbData.ID
+	newData.Status = dbData.Status
+	return &newData, nil
+}
+
+func userToSummary(val User, roles []string) users.UserSummary {
+	var summary = users.UserSummary{
+		ID:          val.ID,
+		Username:    val.Username,
+		Email:       val.Email,
+		PhoneNumber: val.PhoneNumber,
+		Status:      val.Status,
+		Language:    val.Language,
+		Roles:       append([]string{}, roles...),
+		CreatedAt:   val.CreatedAt,
+		PurgedAt:    val.PurgedAt,
+	}
+	if val.DeletedAt.Valid {
+		var deletedAt = val.DeletedAt.Time
+		summary.DeletedAt = &deletedAt
+	}
+	return summary
+}
+
+func (ud *UserData) getUsersRoles(listUser []User) (map[uint][]string, error) {
+	var result = map[uint][]string{}
+	if len(listUser) == 0 {
+		return result, nil
+	}
+
+	var ids []uint
+	for _, val := range listUser {
+		ids = append(ids, val.ID)
+	}
+
+	var rows []struct {
+		UserID uint
+		Name   string
+	}
+
+	var qry = ud.db.Table("user_roles").
+		Select("user_roles.user_id, roles.name").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id IN ?", ids).
+		Order("roles.name ASC").
+		Scan(&rows)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Get Users Roles Error : ", err.Error())
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.UserID] = append(result[row.UserID], row.Name)
+	}
+
+	return result, nil
+}
+
+func encodeUserCursor(user User, sort string) string {
+	var value string
+	switch sort {
+	case "username":
+		value = user.Username
+	case "email":
+		value = user.Email
+	case "created_at":
+		value = user.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(user.ID), 10) + "|" + value))
+}
+
+func decodeUserCursor(cursor, sort string) (any, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, errors.New("ERROR Invalid Cursor")
+	}
+
+	var parts = strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, 0, errors.New("ERROR Invalid Cursor")
+	}
+
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, 0, errors.New("ERROR Invalid Cursor")
+	}
+
+	if sort == "created_at" {
+		createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+		if err != nil {
+			return nil, 0, errors.New("ERROR Invalid Cursor")
+		}
+		return createdAt, uint(id), nil
+	}
+
+	return parts[1], uint(id), nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+func (ud *UserData) GetStatus(id int) (bool, error) {
+	var status bool
+	var qry = ud.db.Model(&User{}).Select("status").Where("id = ?", id).Take(&status)
+
+	if err := qry.Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, errors.New("ERROR User Not Found")
+		}
+		logrus.Error("DATA : Get Status Error : ", err.Error())
+		return false, err
+	}
+
+	return status, nil
+}
+
+func (ud *UserData) Activate(id int) (bool, error) {
+	var qry = ud.db.Model(&User{}).Where("id = ?", id).Updates(map[string]any{
+		"status":       true,
+		"activated_at": gorm.Expr("CASE WHEN status THEN activated_at ELSE ? END", time.Now()),
+	})
+
+	if err := qry.Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (ud *UserData) Deactivate(id int) (bool, error) {
+	var qry = ud.db.Model(User{}).Where("id = ?", id).Updates(map[string]interface{}{"status": false})
+
+	if err := qry.Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (ud *UserData) DeleteUser(id uint) error {
+	var qry = ud.db.Where("id = ?", id).Delete(&User{})
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Delete User Error : ", err.Error())
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("ERROR User Not Found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) RestoreUser(id uint) error {
+	var qry = ud.db.Unscoped().Model(&User{}).
+		Where("id = ? AND deleted_at IS NOT NULL AND purged_at IS NULL", id).
+		Update("deleted_at", nil)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Restore User Error : ", err.Error())
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("ERROR User Not Found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) PurgeDeletedUsers(before time.Time, limit int) (int, []string, error) {
+	var ids []uint
+
+	var qry = ud.db.Unscoped().Model(&User{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND purged_at IS NULL", before).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Pluck("id", &ids)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Get Deleted Users Error : ", err.Error())
+		return 0, nil, err
+	}
+
+	var purged = 0
+	var avatars []string
+	var failed error
+	for _, id := range ids {
+		var avatar string
+		if err := ud.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			avatar, err = purgeUser(tx, id)
+			return err
+		}); err != nil {
+			logrus.Error("DATA : Purge User ", id, " Error : ", err.Error())
+			failed = err
+			continue
+		}
+		if avatar != "" {
+			avatars = append(avatars, avatar)
+		}
+		purged++
+	}
+
+	return purged, avatars, failed
+}
+
+func purgeUser(tx *gorm.DB, id uint) (string, error) {
+	var user = new(User)
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND purged_at IS NULL", id).First(user).Error; err != nil {
+		return "", err
+	}
+
+	var username = strings.ToLower(user.Username)
+	var suffix = make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	var placeholder = "deleted-" + strconv.FormatUint(uint64(id), 10) + "-" + hex.EncodeToString(suffix)
+
+	var qry = tx.Unscoped().Model(&User{}).Where("id = ?", id).UpdateColumns(map[string]any{
+		"username":     placeholder,
+		"email":        placeholder + "@deleted.invalid",
+		"phone_number": "",
+		"password":     "",
+		"status":       false,
+		"avatar_url":   "",
+		"avatar_key":   "",
+		"purged_at":    time.Now(),
+	})
+	if err := qry.Error; err != nil {
+		return "", err
+	}
+
+	for _, model := range []any{&UserSession{}, &UserRefreshToken{}, &UserMFA{}, &UserRecoveryCode{}, &UserPasswordHistory{}, &UserContactChange{}} {
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(model).Error; err != nil {
+			return "", err
+		}
+	}
+
+	for _, model := range []any{&UserResetPass{}, &UserVerification{}, &VerificationRequest{}} {
+		if err := tx.Unscoped().Where("LOWER(username) = ?", username).Delete(model).Error; err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Unscoped().Where("key IN ?", []string{username, strings.ToLower(user.Email)}).Delete(&LoginAttempt{}).Error; err != nil {
+		return "", err
+	}
+
+	var qryEvent = tx.Model(&LockoutEvent{}).
+		Where("user_id = ? OR LOWER(username) = ?", id, username).
+		UpdateColumns(map[string]any{"username": placeholder, "ip_address": ""})
+
+	if err := qryEvent.Error; err != nil {
+		return "", err
+	}
+
+	return user.AvatarKey, nil
+}
+
+func (ud *UserData) UserDashboard(query users.DashboardQuery) (users.UserDashboard, error) {
+	var result users.UserDashboard
+
+	var totals struct {
+		Total       int
+		Active      int
+		Inactive    int
+		NewUsers    int
+		NewVerified int
+	}
+
+	var qry = ud.db.Model(&User{}).Select(`COUNT(*) AS total,
+		COUNT(*) FILTER (WHERE status) AS active,
+		COUNT(*) FILTER (WHERE NOT status) AS inactive,
+		COUNT(*) FILTER (WHERE created_at >= @from AND created_at < @to) AS new_users,
+		COUNT(*) FILTER (WHERE created_at >= @from AND created_at < @to AND verified_at IS NOT NULL) AS new_verified`,
+		sql.Named("from", query.From), sql.Named("to", query.To)).
+		Scan(&totals)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : User Dashboard Totals Error : ", err.Error())
+		return result, err
+	}
+
+	result.TotalUser = totals.Total
+	result.TotalUserActive = totals.Active
+	result.TotalUserInactive = totals.Inactive
+	result.TotalNewUser = totals.NewUsers
+	result.TotalNewVerified = totals.NewVerified
+
+	var series []struct {
+		Period        time.Time
+		Signups       int
+		Verifications int
+		Activations   int
+	}
+
+	qry = ud.db.Raw(`SELECT buckets.period,
+			COUNT(events.kind) FILTER (WHERE events.kind = 'signup') AS signups,
+			COUNT(events.kind) FILTER (WHERE events.kind = 'verification') AS verifications,
+			COUNT(events.kind) FILTER (WHERE events.kind = 'activation') AS activations
+		FROM generate_series(date_trunc(@granularity, CAST(@from AS timestamptz)), CAST(@to AS timestamptz) - interval '1 microsecond', ('1 ' || @granularity)::interval) AS buckets(period)
+		LEFT JOIN (
+			SELECT 'signup' AS kind, created_at AS at FROM users WHERE deleted_at IS NULL AND created_at >= @from AND created_at < @to
+			UNION ALL
+			SELECT 'verification', verified_at FROM users WHERE deleted_at IS NULL AND verified_at >= @from AND verified_at < @to
+			UNION ALL
+			SELECT 'activation', activated_at FROM users WHERE deleted_at IS NULL AND activated_at >= @from AND activated_at < @to
+		) AS events ON date_trunc(@granularity, events.at) = buckets.period
+		GROUP BY buckets.period
+		ORDER BY buckets.period`,
+		sql.Named("granularity", query.Granularity), sql.Named("from", query.From), sql.Named("to", query.To)).
+		Scan(&series)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : User Dashboard Series Error : ", err.Error())
+		return result, err
+	}
+
+	result.Series = []users.DashboardPoint{}
+	for _, val := range series {
+		result.Series = append(result.Series, users.DashboardPoint{
+			Period:        val.Period,
+			Signups:       val.Signups,
+			Verifications: val.Verifications,
+			Activations:   val.Activations,
+		})
+	}
+
+	return result, nil
+}
+
+func (ud *UserData) InsertCodeVerification(username, codeHash string, expiredAt time.Time, mail outbox.Message) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		return insertVerificationCode(tx, username, codeHash, expiredAt, mail)
+	})
+}
+
+func (ud *UserData) TakeCodeVerificationAttempt(username string, maxAttempts int) (*users.UserVerification, error) {
+	var dbData []UserVerification
+
+	var qry = ud.db.Model(&dbData).
+		Clauses(clause.Returning{}).
+		Where("LOWER(username) = LOWER(?) AND attempts < ? AND expired_at > ?", username, maxAttempts, time.Now()).
+		Update("attempts", gorm.Expr("attempts + 1"))
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Take Code Verification Attempt Error : ", err.Error())
+		return nil, err
+	}
+
+	if len(dbData) == 0 {
+		return nil, errors.New("ERROR Code Not Found")
+	}
+
+	var result = new(users.UserVerification)
+	result.Username = dbData[0].Username
+	result.CodeHash = dbData[0].CodeHash
+	result.Attempts = dbData[0].Attempts
+	result.ExpiredAt = dbData[0].ExpiredAt
+
+	return result, nil
+}
+
+func (ud *UserData) UserVerification(username, codeHash string) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		var qry = tx.Where("LOWER(username) = LOWER(?) AND code_hash = ?", username, codeHash).Delete(&UserVerification{})
+		if err := qry.Error; err != nil {
+			logrus.Error("DATA : Delete Code Verification Error : ", err.Error())
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("ERROR Code Not Found")
+		}
+
+		var now = time.Now()
+		var update = map[string]any{
+			"status":       true,
+			"verified_at":  gorm.Expr("COALESCE(verified_at, ?)", now),
+			"activated_at": gorm.Expr("CASE WHEN status THEN activated_at ELSE ? END", now),
+		}
+
+		var qryUser = tx.Model(&User{}).Where("LOWER(username) = LOWER(?) AND verified_at IS NULL AND activated_at IS NULL", username).Updates(update)
+		if err := qryUser.Error; err != nil {
+			logrus.Error("DATA : Update User Verification Error : ", err.Error())
+			return err
+		}
+
+		if qryUser.RowsAffected < 1 {
+			return errors.New("ERROR Unverified User Not Found")
+		}
+
+		return nil
+	})
+}
+
+func (ud *UserData) CreateSession(newSession users.UserSession, refreshToken users.UserRefreshToken) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		var dbSession = new(UserSession)
+		dbSession.UserID = newSession.UserID
+		dbSession.FamilyID = newSession.FamilyID
+		dbSession.Device = newSession.Device
+		dbSession.UserAgent = newSession.UserAgent
+		dbSession.IPAddress = newSession.IPAddress
+		dbSession.LastSeenAt = time.Now()
+
+		if err := tx.Create(dbSession).Error; err != nil {
+			logrus.Error("DATA : Insert Session Error : ", err.Error())
+			return err
+		}
+
+		var dbData = new(UserRefreshToken)
+		dbData.UserID = refreshToken.UserID
+		dbData.FamilyID = refreshToken.FamilyID
+		dbData.TokenHash = refreshToken.TokenHash
+		dbData.Device = refreshToken.Device
+		dbData.ExpiredAt = refreshToken.ExpiredAt
+
+		if err := tx.Create(dbData).Error; err != nil {
+			logrus.Error("DATA : Insert Refresh Token Error : ", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (ud *UserData) GetSessions(userID uint) ([]users.UserSession, error) {
+	var dbData []UserSession
+
+	var qry = ud.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where("family_id IN (?)", ud.db.Model(&UserRefreshToken{}).
+			Select("family_id").
+			Where("user_id = ? AND revoked_at IS NULL AND rotated_at IS NULL AND expired_at > ?", userID, time.Now())).
+		Order("last_seen_at DESC").
+		Find(&dbData)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Get Sessions Error : ", err.Error())
+		return nil, err
+	}
+
+	var result []users.UserSession
+	for _, val := range dbData {
+		result = append(result, sessionToEntity(val))
+	}
+
+	return result, nil
+}
+
+func (ud *UserData) GetSession(id uint) (*users.UserSession, error) {
+	var dbData = new(UserSession)
+
+	if err := ud.db.Where("id = ?", id).First(dbData).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ERROR Session Not Found")
+		}
+		logrus.Error("DATA : Get Session Error : ", err.Error())
+		return nil, err
+	}
+
+	var result = sessionToEntity(*dbData)
+	return &result, nil
+}
+
+func sessionToEntity(dbData UserSession) users.UserSession {
+	var result = users.UserSession{}
+	result.ID = dbData.ID
+	result.UserID = dbData.UserID
+	result.FamilyID = dbData.FamilyID
+	result.Device = dbData.Device
+	result.UserAgent = dbData.UserAgent
+	result.IPAddress = dbData.IPAddress
+	result.CreatedAt = dbData.CreatedAt
+	result.LastSeenAt = dbData.LastSeenAt
+	result.RevokedAt = dbData.RevokedAt
+
+	return result
+}
+
+func (ud *UserData) TouchSession(familyID, ip string) error {
+	var updates = map[string]interface{}{"last_seen_at": time.Now()}
+	if ip != "" {
+		updates["ip_address"] = ip
+	}
+
+	var qry = ud.db.Model(&UserSession{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(updates)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Touch Session Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ud *UserData) GetRefreshToken(tokenHash string) (*users.UserRefreshToken, error) {
+	var dbData = new(UserRefreshToken)
+
+	if err := ud.db.Where("token_hash = ?", tokenHash).First(dbData).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logrus.Error("DATA : Get Refresh Token Error : Data Not Found")
+			return nil, errors.New("ERROR Refresh Token Not Found")
+		}
+		logrus.Error("DATA : Get Refresh Token Error : ", err.Error())
+		return nil, err
+	}
+
+	var result = new(users.UserRefreshToken)
+	result.ID = dbData.ID
+	result.UserID = dbData.UserID
+	result.FamilyID = dbData.FamilyID
+	result.TokenHash = dbData.TokenHash
+	result.Device = dbData.Device
+	result.ExpiredAt = dbData.ExpiredAt
+	result.RotatedAt = dbData.RotatedAt
+	result.RevokedAt = dbData.RevokedAt
+
+	return result, nil
+}
+
+func (ud *UserData) RotateRefreshToken(id uint, newData users.UserRefreshToken) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		var now = time.Now()
+		var qry = tx.Model(&UserRefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+			Update("rotated_at", now)
+
+		if err := qry.Error; err != nil {
+			logrus.Error("DATA : Rotate Refresh Token Error : ", err.Error())
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			logrus.Error("DATA : Rotate Refresh Token Error : Token Already Rotated")
+			return errors.New("ERROR Refresh Token Reused")
+		}
+
+		var dbData = new(UserRefreshToken)
+		dbData.UserID = newData.UserID
+		dbData.FamilyID = newData.FamilyID
+		dbData.TokenHash = newData.TokenHash
+		dbData.Device = newData.Device
+		dbData.ExpiredAt = newData.ExpiredAt
+
+		if err := tx.Create(dbData).Error; err != nil {
+			logrus.Error("DATA : Insert Rotated Refresh Token Error : ", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (ud *UserData) RevokeRefreshTokenFamily(familyID string) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		var now = time.Now()
+		var qry = tx.Model(&UserRefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now)
+
+		if err := qry.Error; err != nil {
+			logrus.Error("DATA : Revoke Refresh Token Family Error : ", err.Error())
+			return err
+		}
+
+		var qrySession = tx.Model(&UserSession{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now)
+
+		if err := qrySession.Error; err != nil {
+			logrus.Error("DATA : Revoke Session Error : ", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (ud *UserData) RevokeUserRefreshTokens(userID uint) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		var now = time.Now()
+		var qry = tx.Model(&UserRefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now)
+
+		if err := qry.Error; err != nil {
+			logrus.Error("DATA : Revoke User Refresh Tokens Error : ", err.Error())
+			return err
+		}
+
+		var qrySession = tx.Model(&UserSession{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now)
+
+		if err := qrySession.Error; err != nil {
+			logrus.Error("DATA : Revoke User Sessions Error : ", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (ud *UserData) RevokeOtherSessions(userID uint, keepFamilyID string) ([]string, error) {
+	var families []string
+
+	var err = ud.db.Transaction(func(tx *gorm.DB) error {
+		var now = time.Now()
+
+		var qryFamily = tx.Model(&UserRefreshToken{}).
+			Distinct("family_id").
+			Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+			Pluck("family_id", &families)
+
+		if err := qryFamily.Error; err != nil {
+			logrus.Error("DATA : Get Refresh Token Families Error : ", err.Error())
+			return err
+		}
+
+		var qry = tx.Model(&UserRefreshToken{}).
+			Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+			Update("revoked_at", now)
+
+		if err := qry.Error; err != nil {
+			logrus.Error("DATA : Revoke Other Refresh Tokens Error : ", err.Error())
+			return err
+		}
+
+		var qrySession = tx.Model(&UserSession{}).
+			Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+			Update("revoked_at", now)
+
+		if err := qrySession.Error; err != nil {
+			logrus.Error("DATA : Revoke Other Sessions Error : ", err.Error())
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return families, nil
+}
+
+func (ud *UserData) InsertRevokedToken(newData users.RevokedToken) error {
+	var dbData = new(RevokedToken)
+	dbData.JTI = newData.JTI
+	dbData.SessionID = newData.SessionID
+	dbData.UserID = newData.UserID
+	dbData.RevokedBefore = newData.RevokedBefore
+	dbData.ExpiredAt = newData.ExpiredAt
+
+	if err := ud.db.Create(dbData).Error; err != nil {
+		logrus.Error("DATA : Insert Revoked Token Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ud *UserData) RevokeAccessTokens(userID uint) error {
+	var now = time.Now()
+	var revokedBefore = now.Truncate(time.Second)
+	return ud.InsertRevokedToken(users.RevokedToken{
+		UserID:        userID,
+		RevokedBefore: &revokedBefore,
+		ExpiredAt:     now.Add(jwt.AccessTokenDuration),
+	})
+}
+
+func (ud *UserData) GetRevokedTokens() ([]users.RevokedToken, error) {
+	var dbData []RevokedToken
+
+	if err := ud.db.Where("expired_at > ?", time.Now()).Find(&dbData).Error; err != nil {
+		logrus.Error("DATA : Get Revoked Tokens Error : ", err.Error())
+		return nil, err
+	}
+
+	var result []users.RevokedToken
+	for _, val := range dbData {
+		result = append(result, users.RevokedToken{
+			JTI:           val.JTI,
+			SessionID:     val.SessionID,
+			UserID:        val.UserID,
+			RevokedBefore: val.RevokedBefore,
+			ExpiredAt:     val.ExpiredAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (ud *UserData) DeleteExpiredRevokedTokens() error {
+	if err := ud.db.Unscoped().Where("expired_at <= ?", time.Now()).Delete(&RevokedToken{}).Error; err != nil {
+		logrus.Error("DATA : Delete Expired Revoked Tokens Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ud *UserData) GetMFA(userID uint) (*users.UserMFA, error) {
+	var dbData = new(UserMFA)
+
+	if err := ud.db.Where("user_id = ?", userID).First(dbData).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ERROR MFA Not Found")
+		}
+		logrus.Error("DATA : Get MFA Error : ", err.Error())
+		return nil, err
+	}
+
+	var result = new(users.UserMFA)
+	result.UserID = dbData.UserID
+	result.Secret = dbData.Secret
+	result.ConfirmedAt = dbData.ConfirmedAt
+	result.LastUsedStep = dbData.LastUsedStep
+	result.FailedAttempts = dbData.FailedAttempts
+	result.LockedUntil = dbData.LockedUntil
+
+	return result, nil
+}
+
+func (ud *UserData) UpsertMFA(userID uint, secret string) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&UserMFA{}).Error; err != nil {
+			logrus.Error("DATA : Delete MFA Error : ", err.Error())
+			return err
+		}
+
+		var newData = new(UserMFA)
+		newData.UserID = userID
+		newData.Secret = secret
+
+		if err := tx.Create(newData).Error; err != nil {
+			logrus.Error("DATA : Insert MFA Error : ", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (ud *UserData) ConfirmMFA(userID uint, step int64, recoveryCodes []string) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		var qry = tx.Model(&UserMFA{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userID).
+			Updates(map[string]interface{}{
+				"confirmed_at":    time.Now(),
+				"last_used_step":  step,
+				"failed_attempts": 0,
+			})
+
+		if err := qry.Error; err != nil {
+			logrus.Error("DATA : Confirm MFA Error : ", err.Error())
+			return err
+		}
+
+		if qry.RowsAffected < 1 {
+			return errors.New("ERROR MFA Not Found")
+		}
+
+		return replaceRecoveryCodes(tx, userID, recoveryCodes)
+	})
+}
+
+func (ud *UserData) UpdateMFAStep(userID uint, step int64) error {
+	var qry = ud.db.Model(&UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]interface{}{
+			"last_used_step":  step,
+			"failed_attempts": 0,
+			"locked_until":    nil,
+		})
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Update MFA Step Error : ", err.Error())
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("ERROR MFA Code Reused")
+	}
+
+	return nil
+}
+
+func (ud *UserData) TakeMFAAttempt(userID uint, maxAttempts int, lockDuration time.Duration) (*users.UserMFA, error) {
+	var dbData []UserMFA
+	var now = time.Now()
+	var attempts = "CASE WHEN locked_until IS NOT NULL THEN 1 ELSE failed_attempts + 1 END"
+
+	var qry = ud.db.Model(&dbData).
+		Clauses(clause.Returning{}).
+		Where("user_id = ? AND confirmed_at IS NOT NULL AND (locked_until IS NULL OR locked_until <= ?)", userID, now).
+		Updates(map[string]interface{}{
+			"failed_attempts": gorm.Expr(attempts),
+			"locked_until":    gorm.Expr("CASE WHEN "+attempts+" >= ? THEN CAST(? AS timestamp) END", maxAttempts, now.Add(lockDuration)),
+		})
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Take MFA Attempt Error : ", err.Error())
+		return nil, err
+	}
+
+	if len(dbData) == 0 {
+		return nil, errors.New("ERROR MFA Locked")
+	}
+
+	var result = new(users.UserMFA)
+	result.UserID = dbData[0].UserID
+	result.Secret = dbData[0].Secret
+	result.ConfirmedAt = dbData[0].ConfirmedAt
+	result.LastUsedStep = dbData[0].LastUsedStep
+	result.FailedAttempts = dbData[0].FailedAttempts
+	result.LockedUntil = dbData[0].LockedUntil
+
+	return result, nil
+}
+
+func (ud *UserData) ResetMFAFailure(userID uint) error {
+	var qry = ud.db.Model(&UserMFA{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"failed_attempts": 0,
+			"locked_until":    nil,
+		})
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Reset MFA Failure Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ud *UserData) ReplaceRecoveryCodes(userID uint, recoveryCodes []string) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, recoveryCodes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, recoveryCodes []string) error {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&UserRecoveryCode{}).Error; err != nil {
+		logrus.Error("DATA : Delete Recovery Codes Error : ", err.Error())
+		return err
+	}
+
+	var newData []UserRecoveryCode
+	for _, code := range recoveryCodes {
+		newData = append(newData, UserRecoveryCode{UserID: userID, CodeHash: code})
+	}
+
+	if len(newData) == 0 {
+		return nil
+	}
+
+	if err := tx.Create(&newData).Error; err != nil {
+		logrus.Error("DATA : Insert Recovery Codes Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ud *UserData) UseRecoveryCode(userID uint, recoveryCode string) error {
+	var qry = ud.db.Model(&UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, recoveryCode).
+		Update("used_at", time.Now())
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Use Recovery Code Error : ", err.Error())
+		return err
+	}
+
+	if qry.RowsAffected < 1 {
+		return errors.New("ERROR Recovery Code Not Found")
+	}
+
+	return nil
+}
+
+func (ud *UserData) DeleteMFA(userID uint) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&UserMFA{}).Error; err != nil {
+			logrus.Error("DATA : Delete MFA Error : ", err.Error())
+			return err
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&UserRecoveryCode{}).Error; err != nil {
+			logrus.Error("DATA : Delete Recovery Codes Error : ", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (ud *UserData) GetLoginAttempt(kind, key string) (*users.LoginAttempt, error) {
+	var dbData = new(LoginAttempt)
+	var result = new(users.LoginAttempt)
+	result.Kind = kind
+	result.Key = key
+
+	if err := ud.db.Where("kind = ? AND key = ?", kind, key).First(dbData).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, nil
+		}
+		logrus.Error("DATA : Get Login Attempt Error : ", err.Error())
+		return nil, err
+	}
+
+	result.FailedCount = dbData.FailedCount
+	result.LastFailedAt = dbData.LastFailedAt
+	result.LockedUntil = dbData.LockedUntil
+
+	return result, nil
+}
+
+func (ud *UserData) IncrementLoginFailure(kind, key string, window time.Duration) (int, error) {
+	var count int
+	var now = time.Now()
+
+	var qry = ud.db.Raw(`
+		INSERT INTO login_attempts (kind, key, failed_count, last_failed_at, created_at, updated_at)
+		VALUES (?, ?, 1, ?, ?, ?)
+		ON CONFLICT (kind, key) DO UPDATE SET
+			failed_count = CASE WHEN login_attempts.last_failed_at < ? THEN 1 ELSE login_attempts.failed_count + 1 END,
+			last_failed_at = EXCLUDED.last_failed_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING failed_count`, kind, key, now, now, now, now.Add(-window)).Scan(&count)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Increment Login Failure Error : ", err.Error())
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (ud *UserData) LockLoginAttempt(kind, key string, until time.Time) error {
+	var qry = ud.db.Model(&LoginAttempt{}).
+		Where("kind = ? AND key = ?", kind, key).
+		Update("locked_until", until)
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Lock Login Attempt Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ud *UserData) ResetLoginAttempt(kind, key string) error {
+	var qry = ud.db.Model(&LoginAttempt{}).
+		Where("kind = ? AND key = ?", kind, key).
+		Updates(map[string]interface{}{
+			"failed_count": 0,
+			"locked_until": nil,
+		})
+
+	if err := qry.Error; err != nil {
+		logrus.Error("DATA : Reset Login Attempt Error : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ud *UserData) InsertLockoutEvent(newData users.LockoutEvent) error {
+	var dbData = new(LockoutEvent)
+	dbData.UserID = newData.UserID
+	dbData.Username = newData.Username
+	dbData.IPAddress = newData.IPAddress
+	dbData.Event = newData.Event
+	dbData.Reason = newData.Reason
+	dbData.ActorID = newData.ActorID
+
+	if err := ud.db.Create(dbData).Error; err != nil {
+		logrus.Error("DATA : Insert Lockout Event Error : ", err.Error())
+		return err
 	}
 
 	return nil
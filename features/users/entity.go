@@ -1,7 +1,12 @@
 package users
 
 import (
+	"e-ticketing-gin/features/audit"
+	"e-ticketing-gin/features/outbox"
+	"e-ticketing-gin/helper/email"
+	"e-ticketing-gin/helper/jwt"
 	"github.com/gin-gonic/gin"
+	"io"
 	"time"
 )
 
@@ -10,39 +15,236 @@ type User struct {
 	Username    string `json:"username"`
 	Email       string `json:"email"`
 	PhoneNumber string `json:"phone_number"`
-	Password    string `json:"password"`
-	IsAdmin     bool   `json:"is_admin"`
+	Password    string `json:"-"`
 	Status      bool   `json:"status"`
+	Language    string `json:"language"`
+	AvatarURL   string `json:"avatar_url"`
+	AvatarKey   string `json:"-"`
+}
+
+type Avatar struct {
+	URL        string            `json:"avatar_url"`
+	Thumbnails map[string]string `json:"thumbnails"`
 }
 
 type UserCredential struct {
-	Username string         `json:"username"`
-	Access   map[string]any `json:"token"`
+	Username         string         `json:"username"`
+	Access           map[string]any `json:"token"`
+	MFARequired      bool           `json:"mfa_required"`
+	MFAToken         string         `json:"mfa_token"`
+	MFASetupRequired bool           `json:"mfa_setup_required"`
+}
+
+type UserMFA struct {
+	UserID         uint       `json:"user_id"`
+	Secret         string     `json:"-"`
+	ConfirmedAt    *time.Time `json:"confirmed_at"`
+	LastUsedStep   int64      `json:"-"`
+	FailedAttempts int        `json:"-"`
+	LockedUntil    *time.Time `json:"locked_until"`
+}
+
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	QRCode string `json:"qr_code"`
 }
 
 type UserResetPass struct {
 	Username  string    `json:"username"`
-	Code      string    `json:"code"`
+	CodeHash  string    `json:"-"`
+	Attempts  int       `json:"attempts"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
 type UserVerification struct {
 	Username  string    `json:"username"`
-	Code      string    `json:"code"`
+	CodeHash  string    `json:"-"`
+	Attempts  int       `json:"attempts"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
+const (
+	ContactKindEmail = "email"
+	ContactKindPhone = "phone"
+)
+
+type ContactChange struct {
+	UserID    uint      `json:"user_id"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	CodeHash  string    `json:"-"`
+	Attempts  int       `json:"attempts"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+type UserRefreshToken struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	FamilyID  string     `json:"family_id"`
+	TokenHash string     `json:"-"`
+	Device    string     `json:"device"`
+	ExpiredAt time.Time  `json:"expired_at"`
+	RotatedAt *time.Time `json:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+type ClientInfo struct {
+	Device    string `json:"device"`
+	UserAgent string `json:"user_agent"`
+	IPAddress string `json:"ip_address"`
+	RequestID string `json:"-"`
+}
+
+type UserSession struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	FamilyID   string     `json:"-"`
+	Device     string     `json:"device"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `json:"current"`
+}
+
+type RevokedToken struct {
+	JTI           string     `json:"jti"`
+	SessionID     string     `json:"session_id"`
+	UserID        uint       `json:"user_id"`
+	RevokedBefore *time.Time `json:"revoked_before"`
+	ExpiredAt     time.Time  `json:"expired_at"`
+}
+
+type LoginAttempt struct {
+	Kind         string     `json:"kind"`
+	Key          string     `json:"key"`
+	FailedCount  int        `json:"failed_count"`
+	LastFailedAt *time.Time `json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until"`
+}
+
+type LockoutEvent struct {
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	IPAddress string    `json:"ip_address"`
+	Event     string    `json:"event"`
+	Reason    string    `json:"reason"`
+	ActorID   uint      `json:"actor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type UpdateProfile struct {
 	Username    string `json:"username"`
 	PhoneNumber string `json:"phone_number"`
 	Email       string `json:"email"`
+	Language    string `json:"language"`
+}
+
+type ProfileUpdate struct {
+	PendingEmail       string `json:"pending_email"`
+	PendingPhoneNumber string `json:"pending_phone_number"`
+}
+
+type UserQuery struct {
+	Search      string
+	Status      *bool
+	Role        string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Sort        string
+	Order       string
+	Page        int
+	Limit       int
+	Cursor      string
+	Deleted     bool
+}
+
+type UserSummary struct {
+	ID          uint       `json:"id"`
+	Username    string     `json:"username"`
+	Email       string     `json:"email"`
+	PhoneNumber string     `json:"phone_number"`
+	Status      bool       `json:"status"`
+	Language    string     `json:"language"`
+	Roles       []string   `json:"roles"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	PurgedAt    *time.Time `json:"purged_at,omitempty"`
+}
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+var ExportColumns = []string{"id", "username", "email", "phone_number", "status", "language", "roles", "created_at", "deleted_at"}
+
+type ImportRow struct {
+	Line        int
+	Username    string
+	Email       string
+	PhoneNumber string
+	Password    string
+	Language    string
+	Errors      []string
+}
+
+type ImportOptions struct {
+	DryRun bool
+	Invite bool
+}
+
+type ImportRowError struct {
+	Line     int      `json:"line"`
+	Username string   `json:"username"`
+	Errors   []string `json:"errors"`
+}
+
+type ImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Invite  bool             `json:"invite"`
+	Total   int              `json:"total"`
+	Valid   int              `json:"valid"`
+	Created int              `json:"created"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+type UserPage struct {
+	Users      []UserSummary
+	Total      int64
+	NextCursor string
+}
+
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+type DashboardQuery struct {
+	From        time.Time
+	To          time.Time
+	Granularity string
+}
+
+type DashboardPoint struct {
+	Period        time.Time `json:"period"`
+	Signups       int       `json:"signups"`
+	Verifications int       `json:"verifications"`
+	Activations   int       `json:"activations"`
 }
 
 type UserDashboard struct {
-	TotalUser         int `json:"total_user"`
-	TotalNewUser      int `json:"total_new_user"`
-	TotalUserActive   int `json:"total_active_user"`
-	TotalUserInactive int `json:"total_inactive_user"`
+	TotalUser         int
+	TotalNewUser      int
+	TotalNewVerified  int
+	TotalUserActive   int
+	TotalUserInactive int
+	ConversionRate    float64
+	Series            []DashboardPoint
 }
 type UserHandlerInterface interface {
 	Register(c *gin.Context)
@@ -50,55 +252,148 @@ type UserHandlerInterface interface {
 	ForgetPasswordWeb(c *gin.Context)
 	ResetPassword(c *gin.Context)
 	UpdateProfile(c *gin.Context)
+	UpdateAvatar(c *gin.Context)
+	ChangePassword(c *gin.Context)
+	DeleteAccount(c *gin.Context)
+	ConfirmContactChange(c *gin.Context)
 	RefreshToken(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
 	Profile(c *gin.Context)
+	GetSessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
+
+	LoginMFA(c *gin.Context)
+	EnrollMFA(c *gin.Context)
+	ConfirmMFA(c *gin.Context)
+	DisableMFA(c *gin.Context)
+	RegenerateRecoveryCodes(c *gin.Context)
 
 	GetUsers(c *gin.Context)
+	ExportUsers(c *gin.Context)
+	ImportUsers(c *gin.Context)
 	ActivateUser(c *gin.Context)
 	DeactivateUser(c *gin.Context)
+	UnlockUser(c *gin.Context)
+	DeleteUser(c *gin.Context)
+	RestoreUser(c *gin.Context)
+	GetUserSessions(c *gin.Context)
+	RevokeUserSession(c *gin.Context)
 
 	UserDashboard(c *gin.Context)
+	GetEmailTemplates(c *gin.Context)
+	PreviewEmail(c *gin.Context)
 	UserVerification(c *gin.Context)
+	ResendVerification(c *gin.Context)
 }
 
 type UserServiceInterface interface {
 	Register(newData User) (*User, error)
-	Login(username string, password string) (*UserCredential, error)
-	ForgetPasswordWeb(username string) error
-	TokenResetVerify(code string) (*UserResetPass, error)
-	ResetPassword(code, username, password string) error
-	UpdateProfile(id int, newData UpdateProfile) (bool, error)
+	Login(username, password string, client ClientInfo) (*UserCredential, error)
+	RefreshToken(refreshToken string, client ClientInfo) (*UserCredential, error)
+	Logout(principal jwt.ExtractToken, refreshToken string) error
+	LogoutAll(userID uint) error
+	IsRevoked(principal jwt.ExtractToken) bool
+	Touch(principal jwt.ExtractToken, ip string)
+	PurgeRevokedTokens() error
+	GetSessions(userID uint, currentSessionID string) ([]UserSession, error)
+	RevokeSession(meta audit.Meta, userID, sessionID uint) error
+
+	LoginMFA(mfaToken, code string, client ClientInfo) (*UserCredential, error)
+	EnrollMFA(userID uint, account string) (*MFAEnrollment, error)
+	ConfirmMFA(userID uint, code string) ([]string, error)
+	DisableMFA(userID uint, code string) error
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, error)
+	ForgetPasswordWeb(meta audit.Meta, username string) error
+	ResetPassword(meta audit.Meta, username, code, password string) error
+	ChangePassword(meta audit.Meta, userID uint, sessionID, currentPassword, password string) error
+	UpdateProfile(id int, newData UpdateProfile) (*ProfileUpdate, error)
+	UpdateAvatar(userID uint, image []byte) (*Avatar, error)
+	ConfirmContactChange(userID uint, kind, code string) error
 	Profile(id int) (*User, error)
 
-	GetAll() ([]User, error)
-	Activate(id int) (bool, error)
-	Deactivate(id int) (bool, error)
+	GetUsers(query UserQuery) (*UserPage, error)
+	ExportUsers(meta audit.Meta, query UserQuery, format string, columns []string, w io.Writer) error
+	ImportUsers(meta audit.Meta, rows []ImportRow, options ImportOptions) (*ImportReport, error)
+	Activate(meta audit.Meta, id int) (bool, error)
+	Deactivate(meta audit.Meta, id int) (bool, error)
+	Unlock(meta audit.Meta, id int) error
+	DeleteAccount(meta audit.Meta, userID uint, password string) (*time.Time, error)
+	DeleteUser(meta audit.Meta, id int) error
+	RestoreUser(meta audit.Meta, id int) error
+	PurgeDeletedUsers() error
 
-	UserDashboard() (UserDashboard, error)
-	UserVerificationCode(username, email string) error
-	UserVerification(code, username string) error
-	TokenVerificationResetVerify(code string) (*UserVerification, error)
+	UserDashboard(query DashboardQuery) (UserDashboard, error)
+	GetEmailTemplates() []string
+	PreviewEmail(name, locale string) (*email.Content, error)
+	UserVerificationCode(username, email, language string) error
+	UserVerification(username, code string) error
+	ResendVerification(identifier string) error
 }
 
 type UserDataInterface interface {
-	Register(newData User) (*User, error)
+	Register(newData User, role string, verification UserVerification, mail outbox.Message) (*User, error)
 	Login(username, password string) (*User, error)
 	GetByID(id int) (User, error)
+	GetAccountByID(id int) (*User, error)
 	GetByUsername(username string) (*User, error)
-	InsertCodeReset(username, code string) error
-	DeleteCodeReset(code string) error
-	GetByCodeReset(code string) (*UserResetPass, error)
-	ResetPassword(code, username, password string) error
-	UpdateProfile(id int, newData UpdateProfile) (bool, error)
+	GetByIdentifier(identifier string) (*User, error)
+	GetUnverifiedUser(identifier string) (*User, error)
+	GetVerificationRequests(username string, since time.Time) ([]time.Time, error)
+	InsertCodeReset(username, codeHash string, expiredAt time.Time, mail outbox.Message) error
+	TakeCodeResetAttempt(username string, maxAttempts int) (*UserResetPass, error)
+	ResetPassword(username, codeHash, password string, keep int) error
+	ChangePassword(userID uint, oldPassword, password string, keep int) error
+	GetPasswordHistory(userID uint, limit int) ([]string, error)
+	UpdateProfile(id int, newData UpdateProfile, changes []ContactChange, mails []outbox.Message) (bool, error)
+	UpdateAvatar(userID uint, url, key string) (string, error)
 	CheckUsername(username string) bool
+	CheckEmail(email string) bool
+	TakeContactChangeAttempt(userID uint, kind string, maxAttempts int) (*ContactChange, error)
+	ApplyContactChange(userID uint, kind, codeHash string) error
 
-	GetAll() ([]User, error)
+	GetUsers(query UserQuery) (*UserPage, error)
+	ExportUsers(query UserQuery, fn func([]UserSummary) error) error
+	ImportUser(newData User, role string, reset *UserResetPass, mail *outbox.Message) (*User, error)
+	GetStatus(id int) (bool, error)
 	Activate(id int) (bool, error)
 	Deactivate(id int) (bool, error)
+	DeleteUser(id uint) error
+	RestoreUser(id uint) error
+	PurgeDeletedUsers(before time.Time, limit int) (int, []string, error)
+
+	UserDashboard(query DashboardQuery) (UserDashboard, error)
+	InsertCodeVerification(username, codeHash string, expiredAt time.Time, mail outbox.Message) error
+	TakeCodeVerificationAttempt(username string, maxAttempts int) (*UserVerification, error)
+	UserVerification(username, codeHash string) error
+
+	CreateSession(newSession UserSession, refreshToken UserRefreshToken) error
+	GetSessions(userID uint) ([]UserSession, error)
+	GetSession(id uint) (*UserSession, error)
+	TouchSession(familyID, ip string) error
+	GetRefreshToken(tokenHash string) (*UserRefreshToken, error)
+	RotateRefreshToken(id uint, newData UserRefreshToken) error
+	RevokeRefreshTokenFamily(familyID string) error
+	RevokeUserRefreshTokens(userID uint) error
+	RevokeOtherSessions(userID uint, keepFamilyID string) ([]string, error)
+
+	InsertRevokedToken(newData RevokedToken) error
+	GetRevokedTokens() ([]RevokedToken, error)
+	DeleteExpiredRevokedTokens() error
+
+	GetMFA(userID uint) (*UserMFA, error)
+	UpsertMFA(userID uint, secret string) error
+	ConfirmMFA(userID uint, step int64, recoveryCodes []string) error
+	UpdateMFAStep(userID uint, step int64) error
+	TakeMFAAttempt(userID uint, maxAttempts int, lockDuration time.Duration) (*UserMFA, error)
+	ResetMFAFailure(userID uint) error
+	ReplaceRecoveryCodes(userID uint, recoveryCodes []string) error
+	UseRecoveryCode(userID uint, recoveryCode string) error
+	DeleteMFA(userID uint) error
 
-	UserDashboard() (UserDashboard, error)
-	InsertCodeVerification(username, code string) error
-	DeleteCodeVerification(code string) error
-	GetByCodeVerification(code string) (*UserVerification, error)
-	UserVerification(code, username string) error
+	GetLoginAttempt(kind, key string) (*LoginAttempt, error)
+	IncrementLoginFailure(kind, key string, window time.Duration) (int, error)
+	LockLoginAttempt(kind, key string, until time.Time) error
+	ResetLoginAttempt(kind, key string) error
+	InsertLockoutEvent(newData LockoutEvent) error
 }
@@ -1,26 +1,58 @@
 package handler
 
 import (
+	"e-ticketing-gin/features/audit"
 	"e-ticketing-gin/features/users"
 	"e-ticketing-gin/helper"
+	"e-ticketing-gin/helper/email"
 	"e-ticketing-gin/helper/jwt"
+	"e-ticketing-gin/helper/password"
+	"e-ticketing-gin/helper/requestid"
+	"encoding/csv"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"io"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
+const (
+	importMaxRows      = 1000
+	importMaxBytes     = 5 << 20
+	importFormOverhead = 64 << 10
+)
+
+const (
+	avatarMaxBytes     = 2 << 20
+	avatarFormOverhead = 64 << 10
+)
+
+var importColumns = []string{"username", "email", "phone_number", "password", "language"}
+
+var importFields = map[string]string{
+	"Username":    "username",
+	"Email":       "email",
+	"PhoneNumber": "phone_number",
+	"Language":    "language",
+}
+
 type UserHandler struct {
 	service users.UserServiceInterface
 	jwt     jwt.JWTInterface
+	policy  password.PolicyInterface
 }
 
-func NewHandler(jwt jwt.JWTInterface, service users.UserServiceInterface) *UserHandler {
+func NewHandler(jwt jwt.JWTInterface, service users.UserServiceInterface, policy password.PolicyInterface) *UserHandler {
 	return &UserHandler{
 		jwt:     jwt,
 		service: service,
+		policy:  policy,
 	}
 }
 
@@ -39,9 +71,8 @@ func (u *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	if !helper.ValidatePassword(req.Password) {
-		errPass := []string{"Password must contain a combination letters, symbols, and numbers"}
-		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Password", errPass))
+	if violations := u.policy.Validate(req.Password, req.Username, req.Email); len(violations) > 0 {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Password", violations))
 		return
 	}
 
@@ -50,11 +81,16 @@ func (u *UserHandler) Register(c *gin.Context) {
 	serviceInput.Username = req.Username
 	serviceInput.PhoneNumber = req.PhoneNumber
 	serviceInput.Password = req.Password
+	serviceInput.Language = req.Language
 
 	res, errData := u.service.Register(*serviceInput)
 	if errData != nil {
 		if strings.Contains(errData.Error(), "Username already registered") {
-			c.JSON(http.StatusBadRequest, helper.FormatResponse("Username Already Registered", nil))
+			c.JSON(http.StatusConflict, helper.FormatResponse("Username Already Registered", nil))
+			return
+		}
+		if strings.Contains(errData.Error(), "Email already registered") {
+			c.JSON(http.StatusConflict, helper.FormatResponse("Email Already Registered", nil))
 			return
 		}
 		logrus.Error("Handler : Register Error : ", errData.Error())
@@ -62,13 +98,6 @@ func (u *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	verificationCode := u.service.UserVerificationCode(req.Username, req.Email)
-	if verificationCode != nil {
-		logrus.Error("Handler : Send Email Error : ", verificationCode.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Send Email Failed", nil))
-		return
-	}
-
 	var response = new(RegisterResponse)
 	response.Email = res.Email
 	response.Username = res.Username
@@ -91,15 +120,15 @@ func (u *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	res, err := u.service.Login(input.Username, input.Password)
+	res, err := u.service.Login(input.Username, input.Password, clientInfo(c, input.Device))
 
 	if err != nil {
-		if strings.Contains(err.Error(), "Not Found") {
-			c.JSON(http.StatusNotFound, helper.FormatResponse("User Not Found", nil))
+		if strings.Contains(err.Error(), "Invalid Credentials") {
+			c.JSON(http.StatusUnauthorized, helper.FormatResponse("Invalid Username or Password", nil))
 			return
 		}
-		if strings.Contains(err.Error(), "Incorrect Password") {
-			c.JSON(http.StatusBadRequest, helper.FormatResponse("Incorrect Password", nil))
+		if strings.Contains(err.Error(), "Locked") || strings.Contains(err.Error(), "Too Many Attempts") {
+			c.JSON(http.StatusTooManyRequests, helper.FormatResponse("Too Many Failed Login Attempts, Try Again Later", nil))
 			return
 		}
 		logrus.Error("Handler : Login Error : ", err.Error())
@@ -110,6 +139,14 @@ func (u *UserHandler) Login(c *gin.Context) {
 	var response = new(LoginResponse)
 	response.Username = res.Username
 	response.Token = res.Access
+	response.MFARequired = res.MFARequired
+	response.MFAToken = res.MFAToken
+	response.MFASetupRequired = res.MFASetupRequired
+
+	if res.MFARequired {
+		c.JSON(http.StatusOK, helper.FormatResponse("Two-Factor Authentication Required", response))
+		return
+	}
 
 	c.JSON(http.StatusOK, helper.FormatResponse("Success Login", response))
 	return
@@ -129,7 +166,7 @@ func (u *UserHandler) ForgetPasswordWeb(c *gin.Context) {
 		return
 	}
 
-	res := u.service.ForgetPasswordWeb(input.Username)
+	res := u.service.ForgetPasswordWeb(audit.NewMeta(c), input.Username)
 
 	if res != nil {
 		logrus.Error("Handler : Send Email Error")
@@ -141,24 +178,54 @@ func (u *UserHandler) ForgetPasswordWeb(c *gin.Context) {
 	return
 }
 func (u *UserHandler) ResetPassword(c *gin.Context) {
-	var token = c.Query("token_reset_password")
-	if token == "" {
-		c.JSON(http.StatusBadRequest, helper.FormatResponse("Token Not Found", nil))
+	var input = new(ResetPasswordInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
 		return
 	}
 
-	dataToken, err := u.service.TokenResetVerify(token)
-	if err != nil {
-		if strings.Contains(err.Error(), "Token Expired") {
-			c.JSON(http.StatusBadRequest, helper.FormatResponse("Token Expired", nil))
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Format Request", errors))
+		return
+	}
+
+	if input.Password != input.PasswordConfirm {
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Password Not Match", nil))
+		return
+	}
+
+	if violations := u.policy.Validate(input.Password, input.Username); len(violations) > 0 {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Password", violations))
+		return
+	}
+
+	result := u.service.ResetPassword(audit.NewMeta(c), input.Username, input.Code, input.Password)
+
+	if result != nil {
+		if strings.Contains(result.Error(), "Code Not Valid") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Code Not Valid or Expired", nil))
 			return
 		}
-		logrus.Error("Handler : Token Reset Error : ", err.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Token Reset Failed", nil))
+		logrus.Error("Handler : Reset Password Error : ", result.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Reset Password Error", nil))
 		return
 	}
 
-	var input = new(ResetPasswordInput)
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Reset Password", nil))
+	return
+}
+
+func (u *UserHandler) ChangePassword(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
+		return
+	}
+
+	var input = new(ChangePasswordInput)
 	if err := c.ShouldBindJSON(input); err != nil {
 		logrus.Error("Handler : Bind Input Error : ", err.Error())
 		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
@@ -167,7 +234,7 @@ func (u *UserHandler) ResetPassword(c *gin.Context) {
 
 	isValid, errors := helper.ValidateJSON(input)
 	if !isValid {
-		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Format Request", errors))
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
 		return
 	}
 
@@ -176,30 +243,75 @@ func (u *UserHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if !helper.ValidatePassword(input.Password) {
-		errPass := []string{"Password must contain a combination letters, symbols, and numbers"}
-		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errPass))
+	if violations := u.policy.Validate(input.Password, ext.Username, ext.Email); len(violations) > 0 {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Password", violations))
 		return
 	}
 
-	result := u.service.ResetPassword(dataToken.Code, dataToken.Username, input.Password)
-
-	if result != nil {
-		logrus.Error("Handler : Reset Password Error")
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Reset Password Error", result))
+	if err := u.service.ChangePassword(audit.NewMeta(c), ext.ID, ext.SessionID, input.CurrentPassword, input.Password); err != nil {
+		if strings.Contains(err.Error(), "Incorrect Password") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Current Password Incorrect", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Recently Used") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Password Has Been Used Recently", nil))
+			return
+		}
+		logrus.Error("Handler : Change Password Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Change Password Error", nil))
 		return
 	}
 
-	c.JSON(http.StatusOK, helper.FormatResponse("Success Reset Password", nil))
-	return
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Change Password", nil))
 }
 
-func (u *UserHandler) UpdateProfile(c *gin.Context) {
-	ext, err := u.jwt.ExtractToken(c)
+func (u *UserHandler) DeleteAccount(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
+		return
+	}
+
+	var input = new(DeleteAccountInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
 
+	res, err := u.service.DeleteAccount(audit.NewMeta(c), ext.ID, input.Password)
 	if err != nil {
-		logrus.Error("Handler : Extract Token Error : ", err.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Extract Token Error", nil))
+		if strings.Contains(err.Error(), "Incorrect Password") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Password Incorrect", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("User Not Found", nil))
+			return
+		}
+		logrus.Error("Handler : Delete Account Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Delete Account Error", nil))
+		return
+	}
+
+	var response = new(DeleteAccountResponse)
+	response.PurgeAfter = *res
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Delete Account, contact support before the purge date to restore it", response))
+}
+
+func (u *UserHandler) UpdateProfile(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
 		return
 	}
 
@@ -211,22 +323,165 @@ func (u *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	if input.Language != "" && email.NormalizeLocale(input.Language) != input.Language {
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Unsupported Language", nil))
+		return
+	}
+
 	var serviceUpdate = new(users.UpdateProfile)
 	serviceUpdate.Email = input.Email
 	serviceUpdate.Username = input.Username
 	serviceUpdate.PhoneNumber = input.PhoneNumber
+	serviceUpdate.Language = input.Language
 
 	res, err := u.service.UpdateProfile(int(id), *serviceUpdate)
 	if err != nil {
+		if strings.Contains(err.Error(), "Username already registered") {
+			c.JSON(http.StatusConflict, helper.FormatResponse("Username Already Registered", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Email already registered") {
+			c.JSON(http.StatusConflict, helper.FormatResponse("Email Already Registered", nil))
+			return
+		}
 		logrus.Error("Handler : Update Profile Error : ", err.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Update Profile Error", res))
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Update Profile Error", nil))
+		return
+	}
+
+	var response = new(ProfileUpdateResponse)
+	response.PendingEmail = res.PendingEmail
+	response.PendingPhoneNumber = res.PendingPhoneNumber
+
+	if response.PendingEmail != "" || response.PendingPhoneNumber != "" {
+		c.JSON(http.StatusAccepted, helper.FormatResponse("Success Update Profile, confirmation code has been sent", response))
 		return
 	}
 
-	c.JSON(http.StatusOK, helper.FormatResponse("Success Update Profile", res))
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Update Profile", response))
 	return
 }
 
+func (u *UserHandler) UpdateAvatar(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, avatarMaxBytes+avatarFormOverhead)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, helper.FormatResponse("Image File Too Large", nil))
+			return
+		}
+		logrus.Error("Handler : Avatar File Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Image File Is Required", nil))
+		return
+	}
+
+	if file.Size > avatarMaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, helper.FormatResponse("Image File Too Large", nil))
+		return
+	}
+
+	source, err := file.Open()
+	if err != nil {
+		logrus.Error("Handler : Open Avatar File Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Image File", nil))
+		return
+	}
+	defer source.Close()
+
+	body, err := io.ReadAll(io.LimitReader(source, avatarMaxBytes+1))
+	if err != nil {
+		logrus.Error("Handler : Read Avatar File Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Image File", nil))
+		return
+	}
+	if len(body) > avatarMaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, helper.FormatResponse("Image File Too Large", nil))
+		return
+	}
+
+	res, err := u.service.UpdateAvatar(ext.ID, body)
+	if err != nil {
+		if strings.Contains(err.Error(), "Unsupported Image Type") {
+			c.JSON(http.StatusUnsupportedMediaType, helper.FormatResponse("Unsupported Image Type, must be jpeg, png, gif or webp", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Invalid Image") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Image File", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Dimension Too Large") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Image Dimension Too Large", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "User Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("User Not Found", nil))
+			return
+		}
+		logrus.Error("Handler : Update Avatar Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Update Avatar Error", nil))
+		return
+	}
+
+	var response = new(AvatarResponse)
+	response.AvatarURL = res.URL
+	response.Thumbnails = res.Thumbnails
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Update Avatar", response))
+}
+
+func (u *UserHandler) ConfirmContactChange(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
+		return
+	}
+
+	var input = new(ConfirmContactChangeInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	if err := u.service.ConfirmContactChange(ext.ID, input.Kind, input.Code); err != nil {
+		if strings.Contains(err.Error(), "Code Not Valid") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Code Not Valid or Expired", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "already registered") {
+			c.JSON(http.StatusConflict, helper.FormatResponse("Email Already Registered", nil))
+			return
+		}
+		logrus.Error("Handler : Confirm Contact Change Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Confirm Contact Change Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Confirm Contact Change", nil))
+}
+
 func (u *UserHandler) RefreshToken(c *gin.Context) {
 	var input = new(RefreshTokenInput)
 	if err := c.ShouldBindJSON(input); err != nil {
@@ -235,154 +490,933 @@ func (u *UserHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	var currentToken = u.jwt.GetCurrentToken(c)
-
-	res, err := u.jwt.RefreshJWT(input.Token, currentToken)
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
 
+	res, err := u.service.RefreshToken(input.Token, clientInfo(c, ""))
 	if err != nil {
+		if strings.Contains(err.Error(), "Not Valid") || strings.Contains(err.Error(), "Expired") || strings.Contains(err.Error(), "Reused") {
+			c.JSON(http.StatusUnauthorized, helper.FormatResponse("Refresh Token Not Valid", nil))
+			return
+		}
 		logrus.Error("Handler : Refresh Token Error : ", err.Error())
 		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Refresh Token Error", nil))
 		return
 	}
 
-	c.JSON(http.StatusOK, helper.FormatResponse("Success Refresh Token", res))
+	var response = new(LoginResponse)
+	response.Username = res.Username
+	response.Token = res.Access
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Refresh Token", response))
 }
-func (u *UserHandler) Profile(c *gin.Context) {
-	ext, err := u.jwt.ExtractToken(c)
-	if err != nil {
-		logrus.Error("Handler : Extract Token Error : ", err.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Extract Token Error", nil))
+func (u *UserHandler) Logout(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
 		return
 	}
 
-	id := int(ext.ID)
+	var input = new(LogoutInput)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(input); err != nil {
+			logrus.Error("Handler : Bind Input Error : ", err.Error())
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+			return
+		}
+	}
 
-	res, err := u.service.Profile(id)
-	if err != nil {
-		logrus.Error("Handler : Profile Error : ", err.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Profile Error", res))
+	if err := u.service.Logout(ext, input.RefreshToken); err != nil {
+		logrus.Error("Handler : Logout Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Logout Error", nil))
 		return
 	}
 
-	var response = new(UserInfo)
-	response.Username = res.Username
-	response.PhoneNumber = res.PhoneNumber
-	response.Email = res.Email
-	response.Role = ext.Role
-
-	c.JSON(http.StatusOK, helper.FormatResponse("Success Get Profile", response))
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Logout", nil))
 }
 
-func (u *UserHandler) GetUsers(c *gin.Context) {
-	if err := u.jwt.ValidateRole(c); !err {
-		logrus.Error("Handler : Unauthorized Access : ", errors.New("you have no permission to access this feature"))
-		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Restricted Access", nil))
+func (u *UserHandler) LogoutAll(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
 		return
 	}
 
-	res, err := u.service.GetAll()
-
-	if err != nil {
-		logrus.Error("Handler : Get Users Error : ", err.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Get Users Error", res))
+	if err := u.service.LogoutAll(ext.ID); err != nil {
+		logrus.Error("Handler : Logout All Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Logout All Error", nil))
 		return
 	}
 
-	c.JSON(http.StatusOK, helper.FormatResponse("Success Get Users", res))
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Logout From All Devices", nil))
 }
-func (u *UserHandler) ActivateUser(c *gin.Context) {
-	if err := u.jwt.ValidateRole(c); !err {
-		logrus.Error("Handler : Unauthorized Access : ", errors.New("you have no permission to activate this feature"))
-		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Restricted Access", nil))
-		return
+
+func clientInfo(c *gin.Context, device string) users.ClientInfo {
+	if device == "" {
+		device = c.Request.UserAgent()
 	}
 
-	id := c.Param("id")
-	userId, err := strconv.Atoi(id)
-	if err != nil {
-		logrus.Error("Handler : Invalid ID : ", err.Error())
-		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
+	return users.ClientInfo{
+		Device:    device,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+		RequestID: requestid.Get(c),
+	}
+}
+
+func (u *UserHandler) GetSessions(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
 		return
 	}
 
-	res, err := u.service.Activate(userId)
+	res, err := u.service.GetSessions(ext.ID, ext.SessionID)
 	if err != nil {
-		logrus.Error("Handler : Activate User Error : ", err.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Activate User Error", res))
+		logrus.Error("Handler : Get Sessions Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Get Sessions Error", nil))
 		return
 	}
 
-	c.JSON(http.StatusOK, helper.FormatResponse("Success Activate User", res))
-	return
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Get Sessions", res))
 }
-func (u *UserHandler) DeactivateUser(c *gin.Context) {
-	if err := u.jwt.ValidateRole(c); !err {
-		logrus.Error("Handler : Unauthorized Access : ", errors.New("you have no permission to deactivate this feature"))
-		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Restricted Access", nil))
+
+func (u *UserHandler) RevokeSession(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
 		return
 	}
 
-	id := c.Param("id")
-	userId, err := strconv.Atoi(id)
+	sessionId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Session ID", nil))
+		return
+	}
+
+	u.revokeSession(c, ext.ID, uint(sessionId))
+}
+
+func (u *UserHandler) GetUserSessions(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		logrus.Error("Handler : Invalid ID : ", err.Error())
 		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
 		return
 	}
 
-	res, err := u.service.Deactivate(userId)
+	res, err := u.service.GetSessions(uint(userId), "")
 	if err != nil {
-		logrus.Error("Handler : Deactivate User Error : ", err.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Deactivate User Error", res))
+		logrus.Error("Handler : Get User Sessions Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Get User Sessions Error", nil))
 		return
 	}
 
-	c.JSON(http.StatusOK, helper.FormatResponse("Success Deactivate User", res))
-	return
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Get User Sessions", res))
 }
 
-func (u *UserHandler) UserDashboard(c *gin.Context) {
-	if err := u.jwt.ValidateRole(c); !err {
-		logrus.Error("Handler : Unauthorized Access : ", errors.New("you have no permission to access this feature"))
-		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Restricted Access", nil))
+func (u *UserHandler) RevokeUserSession(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
 		return
 	}
 
-	res, err := u.service.UserDashboard()
+	sessionId, err := strconv.Atoi(c.Param("session_id"))
 	if err != nil {
-		logrus.Error("Handler : User Dashboard : ", err.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("User Dashboard", res))
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Session ID", nil))
+		return
 	}
 
-	var response = new(DashboardResponse)
-	response.TotalUserBaru = res.TotalNewUser
-	response.TotalUser = res.TotalUser
-	response.TotalUserActive = res.TotalUserActive
-	response.TotalUserInactive = res.TotalUserInactive
-
-	c.JSON(http.StatusOK, helper.FormatResponse("Success Get User Dashboard", response))
-	return
+	u.revokeSession(c, uint(userId), uint(sessionId))
 }
-func (u *UserHandler) UserVerification(c *gin.Context) {
-	var token = c.Query("token_verification")
-	if token == "" {
-		c.JSON(http.StatusBadRequest, helper.FormatResponse("Token Not Found", nil))
-	}
 
-	dataToken, err := u.service.TokenVerificationResetVerify(token)
-	if err != nil {
-		logrus.Error("Handler : Token Reset Error : ", err.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Token Reset Error", nil))
+func (u *UserHandler) revokeSession(c *gin.Context, userID, sessionID uint) {
+	if err := u.service.RevokeSession(audit.NewMeta(c), userID, sessionID); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("Session Not Found", nil))
+			return
+		}
+		logrus.Error("Handler : Revoke Session Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Revoke Session Error", nil))
 		return
 	}
 
-	res := u.service.UserVerification(dataToken.Code, dataToken.Username)
-	if res != nil {
-		logrus.Error("Handler : User Verification Error : ", res.Error())
-		c.JSON(http.StatusInternalServerError, helper.FormatResponse("User Verification Error", nil))
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Revoke Session", nil))
+}
+
+func (u *UserHandler) Profile(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
 		return
 	}
 
-	c.JSON(http.StatusOK, helper.FormatResponse("Success to verification, enable to login", res))
+	id := int(ext.ID)
+
+	res, err := u.service.Profile(id)
+	if err != nil {
+		logrus.Error("Handler : Profile Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Profile Error", res))
+		return
+	}
+
+	var response = new(UserInfo)
+	response.Username = res.Username
+	response.PhoneNumber = res.PhoneNumber
+	response.Email = res.Email
+	response.Language = res.Language
+	response.AvatarURL = res.AvatarURL
+	response.Roles = ext.Roles
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Get Profile", response))
+}
+
+func (u *UserHandler) GetUsers(c *gin.Context) {
+	var input = new(GetUsersInput)
+	if err := c.ShouldBindQuery(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	var query = usersFilter(input.Search, input.Status, input.Role, input.CreatedFrom, input.CreatedTo)
+	query.Sort = input.Sort
+	query.Order = input.Order
+	query.Page = input.Page
+	query.Limit = input.Limit
+	query.Cursor = input.Cursor
+
+	if query.Sort == "" {
+		query.Sort = "id"
+	}
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+	if query.Page == 0 && query.Cursor == "" {
+		query.Page = 1
+	}
+	if query.Cursor != "" {
+		query.Page = 0
+	}
+
+	res, err := u.service.GetUsers(query)
+	if err != nil {
+		if strings.Contains(err.Error(), "Invalid Cursor") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Cursor", nil))
+			return
+		}
+		logrus.Error("Handler : Get Users Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Get Users Error", nil))
+		return
+	}
+
+	var response = []UserListResponse{}
+	for _, val := range res.Users {
+		response = append(response, UserListResponse{
+			ID:          val.ID,
+			Username:    val.Username,
+			Email:       val.Email,
+			PhoneNumber: val.PhoneNumber,
+			Status:      val.Status,
+			Language:    val.Language,
+			Roles:       val.Roles,
+			CreatedAt:   val.CreatedAt,
+			DeletedAt:   val.DeletedAt,
+			PurgedAt:    val.PurgedAt,
+		})
+	}
+
+	var meta = helper.NewPagination(query.Page, query.Limit, res.Total, res.NextCursor)
+	c.JSON(http.StatusOK, helper.FormatResponsePagination("Success Get Users", response, meta))
+}
+func usersFilter(search, status, role, createdFrom, createdTo string) users.UserQuery {
+	var query = users.UserQuery{
+		Search: strings.TrimSpace(search),
+		Role:   role,
+	}
+
+	if status == "deleted" {
+		query.Deleted = true
+	} else if status != "" {
+		var active = status == "active"
+		query.Status = &active
+	}
+
+	if createdFrom != "" {
+		from, _ := time.ParseInLocation("2006-01-02", createdFrom, time.Local)
+		query.CreatedFrom = &from
+	}
+
+	if createdTo != "" {
+		to, _ := time.ParseInLocation("2006-01-02", createdTo, time.Local)
+		to = to.AddDate(0, 0, 1)
+		query.CreatedTo = &to
+	}
+
+	return query
+}
+
+func (u *UserHandler) ExportUsers(c *gin.Context) {
+	var input = new(ExportUsersInput)
+	if err := c.ShouldBindQuery(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	var format = input.Format
+	if format == "" {
+		format = users.ExportFormatCSV
+	}
+
+	var columns = users.ExportColumns
+	if input.Columns != "" {
+		columns = nil
+		for _, column := range strings.Split(input.Columns, ",") {
+			column = strings.ToLower(strings.TrimSpace(column))
+			if !slices.Contains(users.ExportColumns, column) {
+				c.JSON(http.StatusBadRequest, helper.FormatResponse("Unknown Column : "+column, nil))
+				return
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	var query = usersFilter(input.Search, input.Status, input.Role, input.CreatedFrom, input.CreatedTo)
+
+	var contentType = "text/csv; charset=utf-8"
+	if format == users.ExportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"users-%s.%s\"", time.Now().Format("20060102-150405"), format))
+	c.Status(http.StatusOK)
+
+	if err := u.service.ExportUsers(audit.NewMeta(c), query, format, columns, c.Writer); err != nil {
+		logrus.Error("Handler : Export Users Error : ", err.Error())
+	}
+}
+
+func (u *UserHandler) ImportUsers(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, importMaxBytes+importFormOverhead)
+
+	var input = new(ImportUsersInput)
+	if err := c.ShouldBind(input); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, helper.FormatResponse("CSV File Too Large", nil))
+			return
+		}
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, helper.FormatResponse("CSV File Too Large", nil))
+			return
+		}
+		logrus.Error("Handler : Import File Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("CSV File Is Required", nil))
+		return
+	}
+
+	if file.Size > importMaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, helper.FormatResponse("CSV File Too Large", nil))
+		return
+	}
+
+	source, err := file.Open()
+	if err != nil {
+		logrus.Error("Handler : Open Import File Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid CSV File", nil))
+		return
+	}
+	defer source.Close()
+
+	rows, err := u.readImport(source, input.Invite)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.FormatResponse(strings.TrimPrefix(err.Error(), "ERROR "), nil))
+		return
+	}
+
+	res, err := u.service.ImportUsers(audit.NewMeta(c), rows, users.ImportOptions{DryRun: input.DryRun, Invite: input.Invite})
+	if err != nil {
+		logrus.Error("Handler : Import Users Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Import Users Error", nil))
+		return
+	}
+
+	if res.DryRun {
+		c.JSON(http.StatusOK, helper.FormatResponse("Success Validate Import", res))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Import Users", res))
+}
+
+func (u *UserHandler) readImport(source io.Reader, invite bool) ([]users.ImportRow, error) {
+	var reader = csv.NewReader(source)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("ERROR Invalid CSV Header")
+	}
+
+	var index = map[string]int{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !slices.Contains(importColumns, column) {
+			return nil, errors.New("ERROR Unknown Column : " + column)
+		}
+		index[column] = i
+	}
+
+	for _, column := range []string{"username", "email", "phone_number"} {
+		if _, found := index[column]; !found {
+			return nil, errors.New("ERROR Missing Column : " + column)
+		}
+	}
+
+	var field = func(record []string, column string) string {
+		if i, found := index[column]; found && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var result []users.ImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ERROR Invalid CSV At Line %d", line)
+		}
+
+		if len(result) >= importMaxRows {
+			return nil, fmt.Errorf("ERROR Import Is Limited To %d Rows", importMaxRows)
+		}
+
+		var row = users.ImportRow{
+			Line:        line,
+			Username:    field(record, "username"),
+			Email:       field(record, "email"),
+			PhoneNumber: field(record, "phone_number"),
+			Password:    field(record, "password"),
+			Language:    field(record, "language"),
+		}
+
+		var validate = ImportUserInput{
+			Username:    row.Username,
+			Email:       row.Email,
+			PhoneNumber: row.PhoneNumber,
+			Language:    row.Language,
+		}
+		if isValid, errs := helper.ValidateJSON(validate); !isValid {
+			for name, tag := range errs {
+				if tag == "required" {
+					row.Errors = append(row.Errors, importFields[name]+" is required")
+				} else {
+					row.Errors = append(row.Errors, importFields[name]+" is invalid ("+tag+")")
+				}
+			}
+			sort.Strings(row.Errors)
+		}
+
+		switch {
+		case invite && row.Password != "":
+			row.Errors = append(row.Errors, "Password must be empty when sending invitations")
+		case !invite && row.Password == "":
+			row.Errors = append(row.Errors, "Password is required unless sending invitations")
+		case !invite:
+			for _, val := range u.policy.Validate(row.Password, row.Username, row.Email) {
+				row.Errors = append(row.Errors, val.Message)
+			}
+		}
+
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+func (u *UserHandler) ActivateUser(c *gin.Context) {
+	id := c.Param("id")
+	userId, err := strconv.Atoi(id)
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
+		return
+	}
+
+	res, err := u.service.Activate(audit.NewMeta(c), userId)
+	if err != nil {
+		logrus.Error("Handler : Activate User Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Activate User Error", res))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Activate User", res))
 	return
 }
+func (u *UserHandler) DeactivateUser(c *gin.Context) {
+	id := c.Param("id")
+	userId, err := strconv.Atoi(id)
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
+		return
+	}
+
+	res, err := u.service.Deactivate(audit.NewMeta(c), userId)
+	if err != nil {
+		logrus.Error("Handler : Deactivate User Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Deactivate User Error", res))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Deactivate User", res))
+	return
+}
+
+func (u *UserHandler) UnlockUser(c *gin.Context) {
+	id := c.Param("id")
+	userId, err := strconv.Atoi(id)
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
+		return
+	}
+
+	if err := u.service.Unlock(audit.NewMeta(c), userId); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("User Not Found", nil))
+			return
+		}
+		logrus.Error("Handler : Unlock User Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Unlock User Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Unlock User", nil))
+}
+
+func (u *UserHandler) DeleteUser(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
+		return
+	}
+
+	if err := u.service.DeleteUser(audit.NewMeta(c), userId); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("User Not Found", nil))
+			return
+		}
+		logrus.Error("Handler : Delete User Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Delete User Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Delete User", nil))
+}
+
+func (u *UserHandler) RestoreUser(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logrus.Error("Handler : Invalid ID : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid User ID", nil))
+		return
+	}
+
+	if err := u.service.RestoreUser(audit.NewMeta(c), userId); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("Deleted User Not Found or Already Purged", nil))
+			return
+		}
+		logrus.Error("Handler : Restore User Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Restore User Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Restore User", nil))
+}
+
+func (u *UserHandler) UserDashboard(c *gin.Context) {
+	var input = new(DashboardInput)
+	if err := c.ShouldBindQuery(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	var now = time.Now()
+	var query = users.DashboardQuery{
+		To:          time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1),
+		Granularity: input.Granularity,
+	}
+
+	if input.To != "" {
+		to, _ := time.ParseInLocation("2006-01-02", input.To, time.Local)
+		query.To = to.AddDate(0, 0, 1)
+	}
+
+	query.From = query.To.AddDate(0, 0, -30)
+	if input.From != "" {
+		query.From, _ = time.ParseInLocation("2006-01-02", input.From, time.Local)
+	}
+
+	if query.Granularity == "" {
+		query.Granularity = users.GranularityDay
+	}
+
+	res, err := u.service.UserDashboard(query)
+	if err != nil {
+		if strings.Contains(err.Error(), "Invalid Date Range") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Date Range", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Too Large") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Date Range Too Large For Granularity", nil))
+			return
+		}
+		logrus.Error("Handler : User Dashboard : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("User Dashboard Error", nil))
+		return
+	}
+
+	var response = new(DashboardResponse)
+	response.From = query.From.Format("2006-01-02")
+	response.To = query.To.AddDate(0, 0, -1).Format("2006-01-02")
+	response.Granularity = query.Granularity
+	response.TotalUserBaru = res.TotalNewUser
+	response.TotalNewVerified = res.TotalNewVerified
+	response.TotalUser = res.TotalUser
+	response.TotalUserActive = res.TotalUserActive
+	response.TotalUserInactive = res.TotalUserInactive
+	response.ConversionRate = res.ConversionRate
+	response.Series = res.Series
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Get User Dashboard", response))
+	return
+}
+func (u *UserHandler) GetEmailTemplates(c *gin.Context) {
+	var response = map[string]any{
+		"templates": u.service.GetEmailTemplates(),
+		"locales":   email.Locales,
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Get Email Templates", response))
+}
+
+func (u *UserHandler) PreviewEmail(c *gin.Context) {
+	res, err := u.service.PreviewEmail(c.Param("name"), c.DefaultQuery("locale", email.DefaultLocale))
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			c.JSON(http.StatusNotFound, helper.FormatResponse("Template Not Found", nil))
+			return
+		}
+		logrus.Error("Handler : Preview Email Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Preview Email Error", nil))
+		return
+	}
+
+	switch c.Query("format") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(res.HTML))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(res.Text))
+	default:
+		c.JSON(http.StatusOK, helper.FormatResponse("Success Preview Email", res))
+	}
+}
+
+func (u *UserHandler) UserVerification(c *gin.Context) {
+	var input = new(VerificationInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	res := u.service.UserVerification(input.Username, input.Code)
+	if res != nil {
+		if strings.Contains(res.Error(), "Code Not Valid") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Code Not Valid or Expired", nil))
+			return
+		}
+		logrus.Error("Handler : User Verification Error : ", res.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("User Verification Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success to verification, enable to login", nil))
+	return
+}
+
+func (u *UserHandler) ResendVerification(c *gin.Context) {
+	var input = new(ResendVerificationInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	if err := u.service.ResendVerification(input.Identifier); err != nil {
+		logrus.Error("Handler : Resend Verification Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Resend Verification Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("If the account exists and is not verified yet, a new verification code has been sent", nil))
+}
+
+func (u *UserHandler) LoginMFA(c *gin.Context) {
+	var input = new(LoginMFAInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	res, err := u.service.LoginMFA(input.MFAToken, input.Code, clientInfo(c, input.Device))
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Valid") || strings.Contains(err.Error(), "Not Enrolled") {
+			c.JSON(http.StatusUnauthorized, helper.FormatResponse("MFA Token Not Valid", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Invalid MFA Code") {
+			c.JSON(http.StatusUnauthorized, helper.FormatResponse("Invalid Authentication Code", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Locked") {
+			c.JSON(http.StatusTooManyRequests, helper.FormatResponse("Too Many Attempts, Try Again Later", nil))
+			return
+		}
+		logrus.Error("Handler : Login MFA Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Login Process Failed", nil))
+		return
+	}
+
+	var response = new(LoginResponse)
+	response.Username = res.Username
+	response.Token = res.Access
+	response.MFASetupRequired = res.MFASetupRequired
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Login", response))
+}
+
+func (u *UserHandler) EnrollMFA(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
+		return
+	}
+
+	res, err := u.service.EnrollMFA(ext.ID, ext.Username)
+	if err != nil {
+		if strings.Contains(err.Error(), "Already Enabled") {
+			c.JSON(http.StatusConflict, helper.FormatResponse("Two-Factor Authentication Already Enabled", nil))
+			return
+		}
+		logrus.Error("Handler : Enroll MFA Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Enroll MFA Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Scan the QR code and confirm with a code from your authenticator app", res))
+}
+
+func (u *UserHandler) ConfirmMFA(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
+		return
+	}
+
+	var input = new(MFACodeInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	res, err := u.service.ConfirmMFA(ext.ID, input.Code)
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Enrolled") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Two-Factor Authentication Not Enrolled", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Already Enabled") {
+			c.JSON(http.StatusConflict, helper.FormatResponse("Two-Factor Authentication Already Enabled", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Invalid MFA Code") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Authentication Code", nil))
+			return
+		}
+		logrus.Error("Handler : Confirm MFA Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Confirm MFA Error", nil))
+		return
+	}
+
+	var response = new(RecoveryCodesResponse)
+	response.RecoveryCodes = res
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Enable Two-Factor Authentication, store your recovery codes safely", response))
+}
+
+func (u *UserHandler) DisableMFA(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
+		return
+	}
+
+	var input = new(MFACodeInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	if err := u.service.DisableMFA(ext.ID, input.Code); err != nil {
+		if strings.Contains(err.Error(), "Not Enrolled") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Two-Factor Authentication Not Enabled", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Invalid MFA Code") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Authentication Code", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Locked") {
+			c.JSON(http.StatusTooManyRequests, helper.FormatResponse("Too Many Attempts, Try Again Later", nil))
+			return
+		}
+		logrus.Error("Handler : Disable MFA Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Disable MFA Error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Disable Two-Factor Authentication", nil))
+}
+
+func (u *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	ext, ok := jwt.GetPrincipal(c)
+	if !ok {
+		logrus.Error("Handler : Principal Not Found")
+		c.JSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
+		return
+	}
+
+	var input = new(MFACodeInput)
+	if err := c.ShouldBindJSON(input); err != nil {
+		logrus.Error("Handler : Bind Input Error : ", err.Error())
+		c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Input", nil))
+		return
+	}
+
+	isValid, errors := helper.ValidateJSON(input)
+	if !isValid {
+		c.JSON(http.StatusBadRequest, helper.FormatResponseValidation("Invalid Format Request", errors))
+		return
+	}
+
+	res, err := u.service.RegenerateRecoveryCodes(ext.ID, input.Code)
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Enrolled") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Two-Factor Authentication Not Enabled", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Invalid MFA Code") {
+			c.JSON(http.StatusBadRequest, helper.FormatResponse("Invalid Authentication Code", nil))
+			return
+		}
+		if strings.Contains(err.Error(), "Locked") {
+			c.JSON(http.StatusTooManyRequests, helper.FormatResponse("Too Many Attempts, Try Again Later", nil))
+			return
+		}
+		logrus.Error("Handler : Regenerate Recovery Codes Error : ", err.Error())
+		c.JSON(http.StatusInternalServerError, helper.FormatResponse("Regenerate Recovery Codes Error", nil))
+		return
+	}
+
+	var response = new(RecoveryCodesResponse)
+	response.RecoveryCodes = res
+
+	c.JSON(http.StatusOK, helper.FormatResponse("Success Regenerate Recovery Codes", response))
+}
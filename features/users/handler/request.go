@@ -1,15 +1,17 @@
 package handler
 
 type RegisterInput struct {
-	Username    string `json:"username" form:"username" validate:"required"`
+	Username    string `json:"username" form:"username" validate:"required,excludes=@"`
 	PhoneNumber string `json:"phone_number" form:"phone_number" validate:"required"`
-	Email       string `json:"email" form:"email" validate:"required"`
+	Email       string `json:"email" form:"email" validate:"required,email"`
 	Password    string `json:"password" form:"password" validate:"required"`
+	Language    string `json:"language" form:"language" validate:"omitempty,oneof=id en"`
 }
 
 type LoginInput struct {
 	Username string `json:"username" form:"username" validate:"required"`
 	Password string `json:"password" form:"password" validate:"required"`
+	Device   string `json:"device" form:"device"`
 }
 
 type ForgetPasswordInput struct {
@@ -17,16 +19,98 @@ type ForgetPasswordInput struct {
 }
 
 type ResetPasswordInput struct {
+	Username        string `json:"username" form:"username" validate:"required"`
+	Code            string `json:"code" form:"code" validate:"required"`
 	Password        string `json:"password" form:"password" validate:"required"`
 	PasswordConfirm string `json:"password_confirm" form:"password_confirm" validate:"required"`
 }
 
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" form:"current_password" validate:"required"`
+	Password        string `json:"password" form:"password" validate:"required"`
+	PasswordConfirm string `json:"password_confirm" form:"password_confirm" validate:"required"`
+}
+
+type DeleteAccountInput struct {
+	Password string `json:"password" form:"password" validate:"required"`
+}
+
+type VerificationInput struct {
+	Username string `json:"username" form:"username" validate:"required"`
+	Code     string `json:"code" form:"code" validate:"required"`
+}
+
+type ResendVerificationInput struct {
+	Identifier string `json:"identifier" form:"identifier" validate:"required"`
+}
+
+type GetUsersInput struct {
+	Search      string `form:"search"`
+	Status      string `form:"status" validate:"omitempty,oneof=active inactive deleted"`
+	Role        string `form:"role"`
+	CreatedFrom string `form:"created_from" validate:"omitempty,datetime=2006-01-02"`
+	CreatedTo   string `form:"created_to" validate:"omitempty,datetime=2006-01-02"`
+	Sort        string `form:"sort" validate:"omitempty,oneof=id username email created_at"`
+	Order       string `form:"order" validate:"omitempty,oneof=asc desc"`
+	Page        int    `form:"page" validate:"omitempty,min=1"`
+	Limit       int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor      string `form:"cursor"`
+}
+
+type DashboardInput struct {
+	From        string `form:"from" validate:"omitempty,datetime=2006-01-02"`
+	To          string `form:"to" validate:"omitempty,datetime=2006-01-02"`
+	Granularity string `form:"granularity" validate:"omitempty,oneof=day week month"`
+}
+
+type ExportUsersInput struct {
+	Format      string `form:"format" validate:"omitempty,oneof=csv ndjson"`
+	Columns     string `form:"columns"`
+	Search      string `form:"search"`
+	Status      string `form:"status" validate:"omitempty,oneof=active inactive deleted"`
+	Role        string `form:"role"`
+	CreatedFrom string `form:"created_from" validate:"omitempty,datetime=2006-01-02"`
+	CreatedTo   string `form:"created_to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type ImportUsersInput struct {
+	DryRun bool `form:"dry_run"`
+	Invite bool `form:"invite"`
+}
+
+type ImportUserInput struct {
+	Username    string `validate:"required,max=255,excludes=@"`
+	Email       string `validate:"required,email,max=255"`
+	PhoneNumber string `validate:"required,max=255"`
+	Language    string `validate:"omitempty,oneof=id en"`
+}
+
 type UpdateProfile struct {
-	Username    string `json:"username" form:"username" validate:"required"`
-	PhoneNumber string `json:"phone_number" form:"phone_number" validate:"required"`
-	Email       string `json:"email" form:"email" validate:"required"`
+	Username    string `json:"username" form:"username" validate:"omitempty,excludes=@"`
+	PhoneNumber string `json:"phone_number" form:"phone_number"`
+	Email       string `json:"email" form:"email" validate:"omitempty,email"`
+	Language    string `json:"language" form:"language"`
+}
+
+type ConfirmContactChangeInput struct {
+	Kind string `json:"kind" form:"kind" validate:"required,oneof=email phone"`
+	Code string `json:"code" form:"code" validate:"required"`
+}
+
+type LoginMFAInput struct {
+	MFAToken string `json:"mfa_token" form:"mfa_token" validate:"required"`
+	Code     string `json:"code" form:"code" validate:"required"`
+	Device   string `json:"device" form:"device"`
+}
+
+type MFACodeInput struct {
+	Code string `json:"code" form:"code" validate:"required"`
+}
+
+type LogoutInput struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token"`
 }
 
 type RefreshTokenInput struct {
-	Token string `json:"access_token" form:"access_token"`
+	Token string `json:"refresh_token" form:"refresh_token" validate:"required"`
 }
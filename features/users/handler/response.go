@@ -1,5 +1,10 @@
 package handler
 
+import (
+	"e-ticketing-gin/features/users"
+	"time"
+)
+
 type RegisterResponse struct {
 	Username    string `json:"username" form:"username" validate:"required"`
 	PhoneNumber string `json:"phone_number" form:"phone_number" validate:"required"`
@@ -7,20 +12,62 @@ type RegisterResponse struct {
 }
 
 type LoginResponse struct {
-	Username string `json:"username" form:"username" validate:"required"`
-	Token    any    `json:"token"`
+	Username         string `json:"username" form:"username" validate:"required"`
+	Token            any    `json:"token,omitempty"`
+	MFARequired      bool   `json:"mfa_required,omitempty"`
+	MFAToken         string `json:"mfa_token,omitempty"`
+	MFASetupRequired bool   `json:"mfa_setup_required,omitempty"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 type UserInfo struct {
-	Username    string `json:"username" form:"username" validate:"required"`
-	PhoneNumber string `json:"phone_number" form:"phone_number" validate:"required"`
-	Email       string `json:"email" form:"email" validate:"required"`
-	Role        string `json:"role" form:"role"`
+	Username    string   `json:"username" form:"username" validate:"required"`
+	PhoneNumber string   `json:"phone_number" form:"phone_number" validate:"required"`
+	Email       string   `json:"email" form:"email" validate:"required"`
+	Language    string   `json:"language" form:"language"`
+	AvatarURL   string   `json:"avatar_url" form:"avatar_url"`
+	Roles       []string `json:"roles" form:"roles"`
+}
+
+type AvatarResponse struct {
+	AvatarURL  string            `json:"avatar_url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
+
+type ProfileUpdateResponse struct {
+	PendingEmail       string `json:"pending_email,omitempty"`
+	PendingPhoneNumber string `json:"pending_phone_number,omitempty"`
+}
+
+type UserListResponse struct {
+	ID          uint       `json:"id"`
+	Username    string     `json:"username"`
+	Email       string     `json:"email"`
+	PhoneNumber string     `json:"phone_number"`
+	Status      bool       `json:"status"`
+	Language    string     `json:"language"`
+	Roles       []string   `json:"roles"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	PurgedAt    *time.Time `json:"purged_at,omitempty"`
+}
+
+type DeleteAccountResponse struct {
+	PurgeAfter time.Time `json:"purge_after"`
 }
 
 type DashboardResponse struct {
-	TotalUser         int `json:"total_user"`
-	TotalUserBaru     int `json:"total_new_user"`
-	TotalUserActive   int `json:"total_active_user"`
-	TotalUserInactive int `json:"total_inactive_user"`
+	From              string                 `json:"from"`
+	To                string                 `json:"to"`
+	Granularity       string                 `json:"granularity"`
+	TotalUser         int                    `json:"total_user"`
+	TotalUserBaru     int                    `json:"total_new_user"`
+	TotalNewVerified  int                    `json:"total_new_verified"`
+	TotalUserActive   int                    `json:"total_active_user"`
+	TotalUserInactive int                    `json:"total_inactive_user"`
+	ConversionRate    float64                `json:"conversion_rate"`
+	Series            []users.DashboardPoint `json:"series"`
 }
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"e-ticketing-gin/features/users"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	avatarMaxDimension = 4096
+	avatarQuality      = 85
+)
+
+var avatarSizes = []int{256, 128, 64}
+
+var avatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+func (u *UserService) UpdateAvatar(userID uint, body []byte) (*users.Avatar, error) {
+	if !avatarTypes[http.DetectContentType(body)] {
+		return nil, errors.New("ERROR Unsupported Image Type")
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		logrus.Error("Service : Decode Avatar Config Error : ", err.Error())
+		return nil, errors.New("ERROR Invalid Image")
+	}
+	if config.Width > avatarMaxDimension || config.Height > avatarMaxDimension {
+		return nil, errors.New("ERROR Image Dimension Too Large")
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		logrus.Error("Service : Decode Avatar Error : ", err.Error())
+		return nil, errors.New("ERROR Invalid Image")
+	}
+
+	var suffix = make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		logrus.Error("Service : Generate Avatar Key Error : ", err.Error())
+		return nil, errors.New("ERROR Error Update Avatar")
+	}
+	var key = fmt.Sprintf("avatars/%d/%s", userID, hex.EncodeToString(suffix))
+
+	var result = new(users.Avatar)
+	result.Thumbnails = map[string]string{}
+
+	for _, size := range avatarSizes {
+		thumbnail, err := avatarThumbnail(source, size)
+		if err != nil {
+			logrus.Error("Service : Encode Avatar Error : ", err.Error())
+			u.deleteAvatar(key)
+			return nil, errors.New("ERROR Error Update Avatar")
+		}
+
+		url, err := u.store.Put(avatarKey(key, size), "image/jpeg", thumbnail)
+		if err != nil {
+			logrus.Error("Service : Store Avatar Error : ", err.Error())
+			u.deleteAvatar(key)
+			return nil, errors.New("ERROR Error Update Avatar")
+		}
+
+		result.Thumbnails[strconv.Itoa(size)+"x"+strconv.Itoa(size)] = url
+		if result.URL == "" {
+			result.URL = url
+		}
+	}
+
+	previous, err := u.data.UpdateAvatar(userID, result.URL, key)
+	if err != nil {
+		u.deleteAvatar(key)
+		if strings.Contains(err.Error(), "Not Found") {
+			return nil, err
+		}
+		logrus.Error("Service : Error Update Avatar : ", err.Error())
+		return nil, errors.New("ERROR Error Update Avatar")
+	}
+
+	if previous != "" {
+		u.deleteAvatar(previous)
+	}
+
+	return result, nil
+}
+
+func (u *UserService) deleteAvatar(key string) {
+	for _, size := range avatarSizes {
+		if err := u.store.Delete(avatarKey(key, size)); err != nil {
+			logrus.Error("Service : Delete Avatar Error : ", err.Error())
+		}
+	}
+}
+
+func avatarKey(key string, size int) string {
+	return fmt.Sprintf("%s_%d.jpg", key, size)
+}
+
+func avatarThumbnail(source image.Image, size int) ([]byte, error) {
+	var bounds = source.Bounds()
+	var side = min(bounds.Dx(), bounds.Dy())
+	var crop = image.Rect(0, 0, side, side).Add(image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2))
+
+	var canvas = image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(canvas, canvas.Bounds(), source, crop, draw.Over, nil)
+
+	var buf = new(bytes.Buffer)
+	if err := jpeg.Encode(buf, canvas, &jpeg.Options{Quality: avatarQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"e-ticketing-gin/features/users"
+	"e-ticketing-gin/helper/jwt"
+	"sync"
+	"time"
+)
+
+const denylistSyncInterval = time.Second * 30
+
+type denylist struct {
+	mu       sync.RWMutex
+	jti      map[string]time.Time
+	session  map[string]time.Time
+	user     map[uint]time.Time
+	syncedAt time.Time
+}
+
+func newDenylist() *denylist {
+	return &denylist{
+		jti:     map[string]time.Time{},
+		session: map[string]time.Time{},
+		user:    map[uint]time.Time{},
+	}
+}
+
+func (d *denylist) add(token users.RevokedToken) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if token.JTI != "" {
+		d.jti[token.JTI] = token.ExpiredAt
+	}
+
+	if token.SessionID != "" {
+		d.session[token.SessionID] = token.ExpiredAt
+	}
+
+	if token.RevokedBefore != nil {
+		if current, found := d.user[token.UserID]; !found || token.RevokedBefore.After(current) {
+			d.user[token.UserID] = *token.RevokedBefore
+		}
+	}
+}
+
+func (d *denylist) replace(tokens []users.RevokedToken) {
+	var jti = map[string]time.Time{}
+	var session = map[string]time.Time{}
+	var user = map[uint]time.Time{}
+
+	for _, token := range tokens {
+		if token.JTI != "" {
+			jti[token.JTI] = token.ExpiredAt
+		}
+		if token.SessionID != "" {
+			session[token.SessionID] = token.ExpiredAt
+		}
+		if token.RevokedBefore != nil {
+			if current, found := user[token.UserID]; !found || token.RevokedBefore.After(current) {
+				user[token.UserID] = *token.RevokedBefore
+			}
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.jti = jti
+	d.session = session
+	d.user = user
+	d.syncedAt = time.Now()
+}
+
+func (d *denylist) stale() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return time.Since(d.syncedAt) > denylistSyncInterval
+}
+
+func (d *denylist) contains(principal jwt.ExtractToken) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, found := d.jti[principal.JTI]; found {
+		return true
+	}
+
+	if _, found := d.session[principal.SessionID]; found && principal.SessionID != "" {
+		return true
+	}
+
+	if revokedBefore, found := d.user[principal.ID]; found && principal.IssuedAt.Before(revokedBefore) {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"e-ticketing-gin/configs"
+	"e-ticketing-gin/features/users"
+	"e-ticketing-gin/helper/totp"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeMFAData struct {
+	users.UserDataInterface
+	mfa       users.UserMFA
+	recovery  map[string]bool
+	mfaResets int
+}
+
+func (f *fakeMFAData) GetMFA(userID uint) (*users.UserMFA, error) {
+	var result = f.mfa
+	return &result, nil
+}
+
+func (f *fakeMFAData) TakeMFAAttempt(userID uint, maxAttempts int, lockDuration time.Duration) (*users.UserMFA, error) {
+	if f.mfa.LockedUntil != nil && f.mfa.LockedUntil.After(time.Now()) {
+		return nil, errors.New("ERROR MFA Locked")
+	}
+
+	f.mfa.FailedAttempts++
+	if f.mfa.FailedAttempts >= maxAttempts {
+		var until = time.Now().Add(lockDuration)
+		f.mfa.LockedUntil = &until
+	}
+
+	var result = f.mfa
+	return &result, nil
+}
+
+func (f *fakeMFAData) UpdateMFAStep(userID uint, step int64) error {
+	if f.mfa.LastUsedStep >= step {
+		return errors.New("ERROR MFA Code Reused")
+	}
+
+	f.mfa.LastUsedStep = step
+	f.mfa.FailedAttempts = 0
+	f.mfa.LockedUntil = nil
+	return nil
+}
+
+func (f *fakeMFAData) ResetMFAFailure(userID uint) error {
+	f.mfaResets++
+	f.mfa.FailedAttempts = 0
+	f.mfa.LockedUntil = nil
+	return nil
+}
+
+func (f *fakeMFAData) UseRecoveryCode(userID uint, recoveryCode string) error {
+	if !f.recovery[recoveryCode] {
+		return errors.New("ERROR Recovery Code Not Found")
+	}
+
+	delete(f.recovery, recoveryCode)
+	return nil
+}
+
+type fixedTOTP struct {
+	totp.TOTPInterface
+	codes map[string]int64
+}
+
+func (f *fixedTOTP) Validate(secret, code string) (int64, bool) {
+	step, ok := f.codes[code]
+	return step, ok
+}
+
+func newMFATestService() (*UserService, *fakeMFAData) {
+	var helper = totp.NewTOTP(&configs.ProgramConfig{Secret: "test-secret"})
+	var secret, _ = helper.Encrypt("JBSWY3DPEHPK3PXP")
+	var confirmed = time.Now()
+
+	var data = &fakeMFAData{
+		mfa:      users.UserMFA{UserID: 1, Secret: secret, ConfirmedAt: &confirmed, LastUsedStep: 100},
+		recovery: map[string]bool{helper.HashRecoveryCode("abcd-efgh"): true},
+	}
+
+	var service = &UserService{
+		data: data,
+		totp: &fixedTOTP{TOTPInterface: helper, codes: map[string]int64{"111111": 101, "222222": 102, "000100": 100}},
+	}
+
+	return service, data
+}
+
+func TestVerifyMFA(t *testing.T) {
+	var tests = []struct {
+		name          string
+		codes         []string
+		allowRecovery bool
+		wantErrs      []string
+		wantStep      int64
+	}{
+		{name: "fresh code", codes: []string{"111111"}, wantErrs: []string{""}, wantStep: 101},
+		{name: "replayed code", codes: []string{"111111", "111111"}, wantErrs: []string{"", "ERROR Invalid MFA Code"}, wantStep: 101},
+		{name: "older step after newer", codes: []string{"222222", "111111"}, wantErrs: []string{"", "ERROR Invalid MFA Code"}, wantStep: 102},
+		{name: "code for an already used step", codes: []string{"000100"}, wantErrs: []string{"ERROR Invalid MFA Code"}, wantStep: 100},
+		{name: "wrong code", codes: []string{"999999"}, wantErrs: []string{"ERROR Invalid MFA Code"}, wantStep: 100},
+		{name: "recovery code when allowed", codes: []string{"ABCD-EFGH"}, allowRecovery: true, wantErrs: []string{""}, wantStep: 100},
+		{name: "recovery code is single use", codes: []string{"abcd-efgh", "abcd-efgh"}, allowRecovery: true, wantErrs: []string{"", "ERROR Invalid MFA Code"}, wantStep: 100},
+		{name: "recovery code when not allowed", codes: []string{"abcd-efgh"}, wantErrs: []string{"ERROR Invalid MFA Code"}, wantStep: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, data := newMFATestService()
+
+			for i, code := range tt.codes {
+				var got string
+				if err := service.verifyMFA(1, code, tt.allowRecovery); err != nil {
+					got = err.Error()
+				}
+				if got != tt.wantErrs[i] {
+					t.Fatalf("verifyMFA(%s) attempt %d error = %q, want %q", code, i+1, got, tt.wantErrs[i])
+				}
+			}
+
+			if data.mfa.LastUsedStep != tt.wantStep {
+				t.Errorf("last used step = %d, want %d", data.mfa.LastUsedStep, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestVerifyMFALockout(t *testing.T) {
+	service, data := newMFATestService()
+
+	for i := 0; i < mfaMaxAttempts; i++ {
+		if err := service.verifyMFA(1, "999999", true); err == nil || err.Error() != "ERROR Invalid MFA Code" {
+			t.Fatalf("attempt %d error = %v, want invalid code", i+1, err)
+		}
+	}
+
+	if err := service.verifyMFA(1, "111111", true); err == nil || err.Error() != "ERROR MFA Locked" {
+		t.Fatalf("valid code while locked error = %v, want locked", err)
+	}
+	if data.mfa.LastUsedStep != 100 {
+		t.Errorf("last used step = %d, want unchanged 100", data.mfa.LastUsedStep)
+	}
+
+	data.mfa.LockedUntil = nil
+	if err := service.verifyMFA(1, "abcd-efgh", true); err != nil {
+		t.Fatalf("recovery code after lock expiry error = %v", err)
+	}
+	if data.mfaResets != 1 || data.mfa.FailedAttempts != 0 {
+		t.Errorf("recovery code reset = %d, failed attempts = %d", data.mfaResets, data.mfa.FailedAttempts)
+	}
+}
@@ -1,12 +1,27 @@
 package service
 
 import (
+	"e-ticketing-gin/configs"
+	"e-ticketing-gin/features/audit"
+	"e-ticketing-gin/features/outbox"
+	"e-ticketing-gin/features/roles"
 	"e-ticketing-gin/features/users"
+	"e-ticketing-gin/helper"
 	"e-ticketing-gin/helper/email"
 	"e-ticketing-gin/helper/enkrip"
 	"e-ticketing-gin/helper/jwt"
+	"e-ticketing-gin/helper/otp"
+	"e-ticketing-gin/helper/storage"
+	"e-ticketing-gin/helper/totp"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/sirupsen/logrus"
+	"io"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,18 +31,83 @@ type UserService struct {
 	hash  enkrip.HashInterface
 	jwt   jwt.JWTInterface
 	email email.EmailInterface
+	role  roles.RoleServiceInterface
+	audit audit.AuditServiceInterface
+	totp  totp.TOTPInterface
+	otp   otp.OTPInterface
+	store storage.StorageInterface
+	deny  *denylist
+	touch *sessionTouches
+
+	phoneConfirm  bool
+	deletionGrace time.Duration
 }
 
-func New(d users.UserDataInterface, e enkrip.HashInterface, j jwt.JWTInterface, em email.EmailInterface) *UserService {
+const (
+	loginKindAccount     = "account"
+	loginKindIP          = "ip"
+	loginFailureWindow   = time.Hour
+	accountDelayAfter    = 3
+	accountLockAfter     = 10
+	accountLockDuration  = time.Minute * 30
+	ipDelayAfter         = 10
+	ipLockAfter          = 50
+	ipLockDuration       = time.Minute * 15
+	loginMaxDelay        = time.Minute
+	lockoutEventLocked   = "locked"
+	lockoutEventUnlocked = "unlocked"
+)
+
+const (
+	verificationResendCooldown = time.Minute * 2
+	verificationDailyCap       = 5
+)
+
+const (
+	passwordHistorySize = 5
+)
+
+const (
+	purgeBatchSize = 100
+)
+
+const (
+	dashboardMaxPoints = 366
+)
+
+const (
+	invitationExpiry = time.Hour * 72
+)
+
+const (
+	mfaRecoveryCodeCount = 10
+	mfaMaxAttempts       = 5
+	mfaLockDuration      = time.Minute * 15
+)
+
+func New(d users.UserDataInterface, e enkrip.HashInterface, j jwt.JWTInterface, em email.EmailInterface, r roles.RoleServiceInterface, a audit.AuditServiceInterface, t totp.TOTPInterface, o otp.OTPInterface, s storage.StorageInterface, c *configs.ProgramConfig) *UserService {
 	return &UserService{
 		data:  d,
 		hash:  e,
 		jwt:   j,
 		email: em,
+		role:  r,
+		audit: a,
+		totp:  t,
+		otp:   o,
+		store: s,
+		deny:  newDenylist(),
+		touch: newSessionTouches(),
+
+		phoneConfirm:  c.PhoneChangeConfirm,
+		deletionGrace: c.AccountDeletionGrace,
 	}
 }
 
 func (u *UserService) Register(newData users.User) (*users.User, error) {
+	newData.Username = strings.TrimSpace(newData.Username)
+	newData.Email = strings.ToLower(strings.TrimSpace(newData.Email))
+
 	isAlready := u.data.CheckUsername(newData.Username)
 
 	if !isAlready {
@@ -35,6 +115,11 @@ func (u *UserService) Register(newData users.User) (*users.User, error) {
 		return nil, errors.New("ERROR Username already registered")
 	}
 
+	if !u.data.CheckEmail(newData.Email) {
+		logrus.Error("Service : Email already registered")
+		return nil, errors.New("ERROR Email already registered")
+	}
+
 	hashPassword, err := u.hash.HashPassword(newData.Password)
 	if err != nil {
 		logrus.Error("Service : Error Hash Password : ", err.Error())
@@ -42,196 +127,1450 @@ func (u *UserService) Register(newData users.User) (*users.User, error) {
 	}
 
 	newData.Password = hashPassword
-	newData.IsAdmin = false
 	newData.Status = false
+	newData.Language = email.NormalizeLocale(newData.Language)
+
+	code, err := u.otp.GenerateCode()
+	if err != nil {
+		logrus.Error("Service : Error Generate Code Verification : ", err.Error())
+		return nil, errors.New("ERROR Error Register")
+	}
+
+	mail, err := u.codeMail(email.TemplateVerification, newData.Username, newData.Email, newData.Language, code)
+	if err != nil {
+		return nil, errors.New("ERROR Error Register")
+	}
+
+	var verification = users.UserVerification{
+		Username:  newData.Username,
+		CodeHash:  u.otp.HashCode(newData.Username, code),
+		ExpiredAt: time.Now().Add(u.otp.Expiry()),
+	}
 
-	result, err := u.data.Register(newData)
+	result, err := u.data.Register(newData, roles.RoleCustomer, verification, *mail)
 	if err != nil {
+		if strings.Contains(err.Error(), "already registered") {
+			return nil, err
+		}
 		logrus.Error("Service : Error Register : ", err.Error())
 		return nil, errors.New("ERROR Error Register")
 	}
 
+	u.recordDefaultRole(audit.System, result.ID)
+
 	return result, nil
 }
-func (u *UserService) Login(username string, password string) (*users.UserCredential, error) {
+func (u *UserService) Login(username, password string, client users.ClientInfo) (*users.UserCredential, error) {
+	var accountKey = strings.ToLower(strings.TrimSpace(username))
+	var accountID uint
+
+	account, err := u.data.GetByIdentifier(strings.TrimSpace(username))
+	if err != nil && !strings.Contains(err.Error(), "Not Found") {
+		logrus.Error("Service : Error Get By Identifier : ", err.Error())
+		return nil, errors.New("ERROR Process Failed")
+	}
+	if account != nil {
+		accountKey = strings.ToLower(account.Username)
+		accountID = account.ID
+	}
+
+	if err := u.checkLoginThrottle(loginKindAccount, accountKey, accountDelayAfter); err != nil {
+		return nil, err
+	}
+
+	if err := u.checkLoginThrottle(loginKindIP, client.IPAddress, ipDelayAfter); err != nil {
+		return nil, err
+	}
+
 	result, err := u.data.Login(username, password)
 
 	if err != nil {
-		if strings.Contains(err.Error(), "Incorrect Password") {
-			return nil, errors.New("ERROR Incorrect Password")
+		if strings.Contains(err.Error(), "Incorrect Password") || strings.Contains(err.Error(), "Not Found") {
+			u.recordLoginFailure(loginKindAccount, accountKey, accountLockAfter, accountLockDuration, accountID, username, client.IPAddress)
+			u.recordLoginFailure(loginKindIP, client.IPAddress, ipLockAfter, ipLockDuration, accountID, username, client.IPAddress)
+			u.audit.Record(clientMeta(0, username, client), audit.ActionLoginFailed, audit.TargetUser, "", nil, map[string]any{"identifier": username})
+			return nil, errors.New("ERROR Invalid Credentials")
+		}
+		return nil, errors.New("ERROR Process Failed")
+	}
+
+	if err := u.data.ResetLoginAttempt(loginKindAccount, accountKey); err != nil {
+		logrus.Error("Service : Error Reset Login Attempt : ", err.Error())
+	}
+
+	mfa, err := u.data.GetMFA(result.ID)
+	if err != nil && !strings.Contains(err.Error(), "Not Found") {
+		logrus.Error("Service : Error Get MFA : ", err.Error())
+		return nil, errors.New("ERROR Process Failed")
+	}
+
+	if mfa != nil && mfa.ConfirmedAt != nil {
+		mfaToken := u.jwt.GenerateMFAToken(result.ID)
+		if mfaToken == "" {
+			logrus.Error("Service : Error Generate MFA Token")
+			return nil, errors.New("ERROR Generate JWT")
 		}
+
+		response := new(users.UserCredential)
+		response.Username = result.Username
+		response.MFARequired = true
+		response.MFAToken = mfaToken
+
+		return response, nil
+	}
+
+	return u.issueCredential(*result, client)
+}
+
+func (u *UserService) checkLoginThrottle(kind, key string, delayAfter int) error {
+	attempt, err := u.data.GetLoginAttempt(kind, key)
+	if err != nil {
+		logrus.Error("Service : Error Get Login Attempt : ", err.Error())
+		return errors.New("ERROR Process Failed")
+	}
+
+	if attempt.LockedUntil != nil && attempt.LockedUntil.After(time.Now()) {
+		return errors.New("ERROR Account Locked")
+	}
+
+	if attempt.FailedCount < delayAfter || attempt.LastFailedAt == nil {
+		return nil
+	}
+
+	if time.Since(*attempt.LastFailedAt) > loginFailureWindow {
+		return nil
+	}
+
+	var delay = time.Second << uint(attempt.FailedCount-delayAfter)
+	if delay > loginMaxDelay || delay <= 0 {
+		delay = loginMaxDelay
+	}
+
+	if time.Since(*attempt.LastFailedAt) < delay {
+		return errors.New("ERROR Too Many Attempts")
+	}
+
+	return nil
+}
+
+func (u *UserService) recordLoginFailure(kind, key string, lockAfter int, lockDuration time.Duration, userID uint, username, ip string) {
+	count, err := u.data.IncrementLoginFailure(kind, key, loginFailureWindow)
+	if err != nil {
+		logrus.Error("Service : Error Increment Login Failure : ", err.Error())
+		return
+	}
+
+	if count < lockAfter {
+		return
+	}
+
+	if err := u.data.LockLoginAttempt(kind, key, time.Now().Add(lockDuration)); err != nil {
+		logrus.Error("Service : Error Lock Login Attempt : ", err.Error())
+		return
+	}
+
+	var event = users.LockoutEvent{
+		UserID:    userID,
+		Username:  username,
+		IPAddress: ip,
+		Event:     lockoutEventLocked,
+		Reason:    "too many failed login attempts by " + kind,
+	}
+
+	if err := u.data.InsertLockoutEvent(event); err != nil {
+		logrus.Error("Service : Error Insert Lockout Event : ", err.Error())
+	}
+}
+
+func (u *UserService) issueCredential(user users.User, client users.ClientInfo) (*users.UserCredential, error) {
+	var familyID = jwt.GenerateRandomToken(16)
+
+	response, refreshToken, err := u.generateCredential(user, familyID, client.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	var session = users.UserSession{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		Device:    client.Device,
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+	}
+
+	if err := u.data.CreateSession(session, *refreshToken); err != nil {
+		logrus.Error("Service : Error Create Session : ", err.Error())
+		return nil, errors.New("ERROR Process Failed")
+	}
+
+	u.audit.Record(clientMeta(user.ID, user.Username, client), audit.ActionLogin, audit.TargetUser, strconv.Itoa(int(user.ID)), nil, map[string]any{"device": client.Device})
+
+	return response, nil
+}
+
+func clientMeta(userID uint, username string, client users.ClientInfo) audit.Meta {
+	return audit.Meta{
+		ActorID:   userID,
+		Actor:     username,
+		IPAddress: client.IPAddress,
+		RequestID: client.RequestID,
+	}
+}
+
+func (u *UserService) RefreshToken(refreshToken string, client users.ClientInfo) (*users.UserCredential, error) {
+	current, err := u.data.GetRefreshToken(jwt.HashToken(refreshToken))
+	if err != nil {
 		if strings.Contains(err.Error(), "Not Found") {
-			return nil, errors.New("ERROR Not Found")
+			return nil, errors.New("ERROR Refresh Token Not Valid")
 		}
+		logrus.Error("Service : Error Get Refresh Token : ", err.Error())
 		return nil, errors.New("ERROR Process Failed")
 	}
 
-	role := "user"
+	if current.RevokedAt != nil || current.RotatedAt != nil {
+		logrus.Error("Service : Refresh Token Reused, Revoking Family : ", current.FamilyID)
+		if current.RevokedAt == nil {
+			if err := u.revokeFamily(current.UserID, current.FamilyID); err != nil {
+				logrus.Error("Service : Error Revoke Refresh Token Family : ", err.Error())
+			}
+		}
+		return nil, errors.New("ERROR Refresh Token Reused")
+	}
 
-	if result.IsAdmin {
-		role = "admin"
+	if current.ExpiredAt.Before(time.Now()) {
+		return nil, errors.New("ERROR Refresh Token Expired")
 	}
 
-	tokenData := u.jwt.GenerateJWT(result.ID, result.Username, result.Email, result.PhoneNumber, role)
+	user, err := u.data.GetByID(int(current.UserID))
+	if err != nil {
+		logrus.Error("Service : Error Get ByID : ", err.Error())
+		return nil, errors.New("ERROR Refresh Token Not Valid")
+	}
 
-	if tokenData == nil {
-		logrus.Error("Service : Error Generate JWT")
-		return nil, errors.New("ERROR Generate JWT")
+	response, newRefreshToken, err := u.generateCredential(user, current.FamilyID, current.Device)
+	if err != nil {
+		return nil, err
 	}
 
-	response := new(users.UserCredential)
-	response.Access = tokenData
-	response.Username = result.Username
+	if err := u.data.RotateRefreshToken(current.ID, *newRefreshToken); err != nil {
+		if strings.Contains(err.Error(), "Reused") {
+			if errRevoke := u.revokeFamily(current.UserID, current.FamilyID); errRevoke != nil {
+				logrus.Error("Service : Error Revoke Refresh Token Family : ", errRevoke.Error())
+			}
+			return nil, errors.New("ERROR Refresh Token Reused")
+		}
+		logrus.Error("Service : Error Rotate Refresh Token : ", err.Error())
+		return nil, errors.New("ERROR Process Failed")
+	}
+
+	if err := u.data.TouchSession(current.FamilyID, client.IPAddress); err != nil {
+		logrus.Error("Service : Error Touch Session : ", err.Error())
+	}
 
 	return response, nil
 }
 
-func (u *UserService) ForgetPasswordWeb(username string) error {
-	user, err := u.data.GetByUsername(username)
-	if err != nil {
-		logrus.Error("Service : Error Get By Username : ", err.Error())
-		return errors.New("ERROR Error Get By Username")
+func (u *UserService) revokeFamily(userID uint, familyID string) error {
+	if err := u.data.RevokeRefreshTokenFamily(familyID); err != nil {
+		return err
+	}
+
+	var revoked = users.RevokedToken{
+		SessionID: familyID,
+		UserID:    userID,
+		ExpiredAt: time.Now().Add(jwt.AccessTokenDuration),
 	}
 
-	email := user.Email
+	if err := u.data.InsertRevokedToken(revoked); err != nil {
+		return err
+	}
+	u.deny.add(revoked)
 
-	username = user.Username
-	header, htmlBody, code := u.email.HTMLBodyReset(username)
+	return nil
+}
 
-	if err := u.data.InsertCodeReset(username, code); err != nil {
-		logrus.Error("Service : Error Insert Code Reset User : ", err.Error())
-		return errors.New("ERROR Error Insert Code Reset User")
+func (u *UserService) Logout(principal jwt.ExtractToken, refreshToken string) error {
+	var revoked = users.RevokedToken{
+		JTI:       principal.JTI,
+		SessionID: principal.SessionID,
+		UserID:    principal.ID,
+		ExpiredAt: principal.ExpiredAt,
 	}
 
-	errSend := u.email.SendEmail(email, header, htmlBody)
-	if errSend != nil {
-		logrus.Error("Service : Error Sending Email : ", err.Error())
-		return errors.New("ERROR Sending Email")
+	if err := u.data.InsertRevokedToken(revoked); err != nil {
+		logrus.Error("Service : Error Insert Revoked Token : ", err.Error())
+		return errors.New("ERROR Error Logout")
+	}
+	u.deny.add(revoked)
+
+	if principal.SessionID != "" {
+		if err := u.data.RevokeRefreshTokenFamily(principal.SessionID); err != nil {
+			logrus.Error("Service : Error Revoke Refresh Token Family : ", err.Error())
+			return errors.New("ERROR Error Logout")
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	current, err := u.data.GetRefreshToken(jwt.HashToken(refreshToken))
+	if err != nil || current.UserID != principal.ID {
+		return nil
+	}
+
+	if err := u.data.RevokeRefreshTokenFamily(current.FamilyID); err != nil {
+		logrus.Error("Service : Error Revoke Refresh Token Family : ", err.Error())
+		return errors.New("ERROR Error Logout")
 	}
 
 	return nil
 }
 
-func (u *UserService) TokenResetVerify(code string) (*users.UserResetPass, error) {
-	result, err := u.data.GetByCodeReset(code)
+func (u *UserService) LogoutAll(userID uint) error {
+	var now = time.Now()
+	var revokedBefore = now.Truncate(time.Second)
+	var revoked = users.RevokedToken{
+		UserID:        userID,
+		RevokedBefore: &revokedBefore,
+		ExpiredAt:     now.Add(jwt.AccessTokenDuration),
+	}
 
-	if err != nil {
-		logrus.Error("Service : Error Get By Code : ", err.Error())
-		return nil, errors.New("ERROR Error Get By Code")
+	if err := u.data.InsertRevokedToken(revoked); err != nil {
+		logrus.Error("Service : Error Insert Revoked Token : ", err.Error())
+		return errors.New("ERROR Error Logout")
 	}
+	u.deny.add(revoked)
 
-	if result.ExpiredAt.Before(time.Now()) {
-		return nil, errors.New("ERROR Token Expired")
+	if err := u.data.RevokeUserRefreshTokens(userID); err != nil {
+		logrus.Error("Service : Error Revoke User Refresh Tokens : ", err.Error())
+		return errors.New("ERROR Error Logout")
 	}
 
-	return result, nil
+	return nil
 }
 
-func (u *UserService) ResetPassword(code, username, password string) error {
-	hashPassword, err := u.hash.HashPassword(password)
+func (u *UserService) IsRevoked(principal jwt.ExtractToken) bool {
+	if u.deny.stale() {
+		res, err := u.data.GetRevokedTokens()
+		if err != nil {
+			logrus.Error("Service : Error Get Revoked Tokens : ", err.Error())
+		} else {
+			u.deny.replace(res)
+		}
+	}
+
+	return u.deny.contains(principal)
+}
+
+func (u *UserService) Touch(principal jwt.ExtractToken, ip string) {
+	if principal.SessionID == "" || !u.touch.due(principal.SessionID) {
+		return
+	}
+
+	if err := u.data.TouchSession(principal.SessionID, ip); err != nil {
+		logrus.Error("Service : Error Touch Session : ", err.Error())
+	}
+}
+
+func (u *UserService) GetSessions(userID uint, currentSessionID string) ([]users.UserSession, error) {
+	res, err := u.data.GetSessions(userID)
 	if err != nil {
-		logrus.Error("Service : Error Hash Password : ", err.Error())
-		return errors.New("ERROR Error Hashing Password")
+		logrus.Error("Service : Error Get Sessions : ", err.Error())
+		return nil, errors.New("ERROR Error Get Sessions")
 	}
 
-	password = hashPassword
+	for i := range res {
+		res[i].Current = res[i].FamilyID == currentSessionID
+	}
 
-	if err := u.data.ResetPassword(code, username, password); err != nil {
-		logrus.Error("Service : Error Reset Password : ", err.Error())
-		return errors.New("ERROR Error Reset Password")
+	return res, nil
+}
+
+func (u *UserService) RevokeSession(meta audit.Meta, userID, sessionID uint) error {
+	session, err := u.data.GetSession(sessionID)
+	if err != nil || session.UserID != userID {
+		return errors.New("ERROR Session Not Found")
+	}
+
+	if session.RevokedAt != nil {
+		return nil
+	}
+
+	if err := u.revokeFamily(session.UserID, session.FamilyID); err != nil {
+		logrus.Error("Service : Error Revoke Session : ", err.Error())
+		return errors.New("ERROR Error Revoke Session")
+	}
+
+	u.audit.Record(meta, audit.ActionSessionRevoke, audit.TargetSession, strconv.Itoa(int(session.ID)),
+		map[string]any{"user_id": session.UserID, "device": session.Device, "revoked": false},
+		map[string]any{"user_id": session.UserID, "device": session.Device, "revoked": true})
+
+	return nil
+}
+
+func (u *UserService) PurgeRevokedTokens() error {
+	if err := u.data.DeleteExpiredRevokedTokens(); err != nil {
+		logrus.Error("Service : Error Purge Revoked Tokens : ", err.Error())
+		return errors.New("ERROR Error Purge Revoked Tokens")
 	}
 
 	return nil
 }
-func (u *UserService) UpdateProfile(id int, newData users.UpdateProfile) (bool, error) {
-	res, err := u.data.UpdateProfile(id, newData)
 
+func (u *UserService) generateCredential(user users.User, familyID, device string) (*users.UserCredential, *users.UserRefreshToken, error) {
+	access, err := u.role.GetUserAccess(user.ID)
 	if err != nil {
-		logrus.Error("Service : Error Update Profile : ", err.Error())
-		return false, errors.New("ERROR Error Update Profile")
+		logrus.Error("Service : Error Get User Access : ", err.Error())
+		return nil, nil, errors.New("ERROR Process Failed")
 	}
 
-	return res, nil
+	var setupRequired = false
+	if access.MFARequired {
+		mfa, err := u.data.GetMFA(user.ID)
+		if err != nil || mfa.ConfirmedAt == nil {
+			setupRequired = true
+			access.Permissions = nil
+		}
+	}
+
+	var principal = jwt.ExtractToken{
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		Roles:       access.Roles,
+		Permissions: access.Permissions,
+		SessionID:   familyID,
+	}
+
+	tokenData := u.jwt.GenerateJWT(principal)
+	if tokenData == nil || familyID == "" {
+		logrus.Error("Service : Error Generate JWT")
+		return nil, nil, errors.New("ERROR Generate JWT")
+	}
+
+	var refreshToken = new(users.UserRefreshToken)
+	refreshToken.UserID = user.ID
+	refreshToken.FamilyID = familyID
+	refreshToken.TokenHash = jwt.HashToken(tokenData["refresh_token"].(string))
+	refreshToken.Device = device
+	refreshToken.ExpiredAt = time.Now().Add(jwt.RefreshTokenDuration)
+
+	response := new(users.UserCredential)
+	response.Access = tokenData
+	response.Username = user.Username
+	response.MFASetupRequired = setupRequired
+
+	return response, refreshToken, nil
 }
-func (u *UserService) Profile(id int) (*users.User, error) {
-	res, err := u.data.GetByID(id)
+
+func (u *UserService) LoginMFA(mfaToken, code string, client users.ClientInfo) (*users.UserCredential, error) {
+	userID, err := u.jwt.ValidateMFAToken(mfaToken)
+	if err != nil {
+		logrus.Error("Service : Error Validate MFA Token : ", err.Error())
+		return nil, errors.New("ERROR MFA Token Not Valid")
+	}
+
+	if err := u.verifyMFA(userID, code, true); err != nil {
+		return nil, err
+	}
+
+	user, err := u.data.GetByID(int(userID))
 	if err != nil {
 		logrus.Error("Service : Error Get ByID : ", err.Error())
-		return nil, errors.New("ERROR Error Get ByID")
+		return nil, errors.New("ERROR MFA Token Not Valid")
 	}
-	return &res, nil
+
+	return u.issueCredential(user, client)
 }
 
-func (u *UserService) GetAll() ([]users.User, error) {
-	res, err := u.data.GetAll()
+func (u *UserService) EnrollMFA(userID uint, account string) (*users.MFAEnrollment, error) {
+	mfa, err := u.data.GetMFA(userID)
+	if err == nil && mfa.ConfirmedAt != nil {
+		return nil, errors.New("ERROR MFA Already Enabled")
+	}
+
+	secret, err := u.totp.GenerateSecret()
 	if err != nil {
-		logrus.Error("Service : Error GetAll : ", err.Error())
-		return nil, errors.New("ERROR Error GetAll")
+		logrus.Error("Service : Error Generate MFA Secret : ", err.Error())
+		return nil, errors.New("ERROR Error Enroll MFA")
 	}
 
-	return res, nil
-}
-func (u *UserService) Activate(id int) (bool, error) {
-	res, err := u.data.Activate(id)
+	sealed, err := u.totp.Encrypt(secret)
 	if err != nil {
-		logrus.Error("Service : Error Activate : ", err.Error())
-		return false, errors.New("ERROR Error Activate")
+		logrus.Error("Service : Error Encrypt MFA Secret : ", err.Error())
+		return nil, errors.New("ERROR Error Enroll MFA")
 	}
-	return res, nil
-}
-func (u *UserService) Deactivate(id int) (bool, error) {
-	res, err := u.data.Deactivate(id)
+
+	if err := u.data.UpsertMFA(userID, sealed); err != nil {
+		logrus.Error("Service : Error Upsert MFA : ", err.Error())
+		return nil, errors.New("ERROR Error Enroll MFA")
+	}
+
+	var uri = u.totp.URI(secret, account)
+	png, err := u.totp.QRCode(uri)
 	if err != nil {
-		logrus.Error("Service : Error Deactivate : ", err.Error())
-		return false, errors.New("ERROR Error Deactivate")
+		logrus.Error("Service : Error Generate QR Code : ", err.Error())
+		return nil, errors.New("ERROR Error Enroll MFA")
 	}
-	return res, nil
+
+	var result = new(users.MFAEnrollment)
+	result.Secret = secret
+	result.URI = uri
+	result.QRCode = "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+
+	return result, nil
 }
 
-func (u *UserService) UserDashboard() (users.UserDashboard, error) {
-	res, err := u.data.UserDashboard()
+func (u *UserService) ConfirmMFA(userID uint, code string) ([]string, error) {
+	mfa, err := u.data.GetMFA(userID)
 	if err != nil {
-		logrus.Error("Service : Error User Dashboard : ", err.Error())
-		return res, errors.New("ERROR Error User Dashboard")
+		return nil, errors.New("ERROR MFA Not Enrolled")
 	}
 
-	return res, nil
-}
-func (u *UserService) UserVerificationCode(username, email string) error {
-	header, htmlBody, code := u.email.HTMLBodyVerification(username)
+	if mfa.ConfirmedAt != nil {
+		return nil, errors.New("ERROR MFA Already Enabled")
+	}
 
-	if err := u.data.InsertCodeVerification(username, code); err != nil {
-		logrus.Error("Service : Error Insert Code Verification : ", err.Error())
-		return errors.New("ERROR Error Insert Code Verification")
+	secret, err := u.totp.Decrypt(mfa.Secret)
+	if err != nil {
+		logrus.Error("Service : Error Decrypt MFA Secret : ", err.Error())
+		return nil, errors.New("ERROR Error Confirm MFA")
 	}
 
-	errSend := u.email.SendEmail(email, header, htmlBody)
+	step, ok := u.totp.Validate(secret, code)
+	if !ok {
+		return nil, errors.New("ERROR Invalid MFA Code")
+	}
 
-	if errSend != nil {
-		logrus.Error("Service : Error Send Email Verification : ", errSend.Error())
-		return errors.New("ERROR Send Email Verification")
+	codes, hashes, err := u.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	if err := u.data.ConfirmMFA(userID, step, hashes); err != nil {
+		logrus.Error("Service : Error Confirm MFA : ", err.Error())
+		return nil, errors.New("ERROR Error Confirm MFA")
+	}
+
+	return codes, nil
 }
-func (u *UserService) UserVerification(code, username string) error {
-	if err := u.data.UserVerification(username, code); err != nil {
-		logrus.Error("Service : Error User Verification : ", err.Error())
-		return errors.New("ERROR Error User Verification")
+
+func (u *UserService) DisableMFA(userID uint, code string) error {
+	if err := u.verifyMFA(userID, code, true); err != nil {
+		return err
+	}
+
+	if err := u.data.DeleteMFA(userID); err != nil {
+		logrus.Error("Service : Error Delete MFA : ", err.Error())
+		return errors.New("ERROR Error Disable MFA")
 	}
 
 	return nil
 }
-func (u *UserService) TokenVerificationResetVerify(code string) (*users.UserVerification, error) {
-	res, err := u.data.GetByCodeVerification(code)
+
+func (u *UserService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	if err := u.verifyMFA(userID, code, false); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := u.generateRecoveryCodes()
 	if err != nil {
-		logrus.Error("Service : Error Get By Code : ", err.Error())
-		return nil, errors.New("ERROR Error Get By Code")
+		return nil, err
 	}
 
-	if res.ExpiredAt.Before(time.Now()) {
-		return nil, errors.New("ERROR Token Expired")
+	if err := u.data.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		logrus.Error("Service : Error Replace Recovery Codes : ", err.Error())
+		return nil, errors.New("ERROR Error Regenerate Recovery Codes")
 	}
 
-	return res, nil
+	return codes, nil
+}
+
+func (u *UserService) verifyMFA(userID uint, code string, allowRecovery bool) error {
+	mfa, err := u.data.GetMFA(userID)
+	if err != nil || mfa.ConfirmedAt == nil {
+		return errors.New("ERROR MFA Not Enrolled")
+	}
+
+	if mfa.LockedUntil != nil && mfa.LockedUntil.After(time.Now()) {
+		return errors.New("ERROR MFA Locked")
+	}
+
+	mfa, err = u.data.TakeMFAAttempt(userID, mfaMaxAttempts, mfaLockDuration)
+	if err != nil {
+		if strings.Contains(err.Error(), "Locked") {
+			return err
+		}
+		logrus.Error("Service : Error Take MFA Attempt : ", err.Error())
+		return errors.New("ERROR Process Failed")
+	}
+
+	secret, err := u.totp.Decrypt(mfa.Secret)
+	if err != nil {
+		logrus.Error("Service : Error Decrypt MFA Secret : ", err.Error())
+		return errors.New("ERROR Process Failed")
+	}
+
+	if step, ok := u.totp.Validate(secret, code); ok {
+		if err := u.data.UpdateMFAStep(userID, step); err != nil {
+			if strings.Contains(err.Error(), "Reused") {
+				return errors.New("ERROR Invalid MFA Code")
+			}
+			return errors.New("ERROR Process Failed")
+		}
+		return nil
+	}
+
+	if allowRecovery {
+		if err := u.data.UseRecoveryCode(userID, u.totp.HashRecoveryCode(code)); err == nil {
+			if err := u.data.ResetMFAFailure(userID); err != nil {
+				logrus.Error("Service : Error Reset MFA Failure : ", err.Error())
+			}
+			return nil
+		}
+	}
+
+	return errors.New("ERROR Invalid MFA Code")
+}
+
+func (u *UserService) generateRecoveryCodes() ([]string, []string, error) {
+	codes, err := u.totp.GenerateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		logrus.Error("Service : Error Generate Recovery Codes : ", err.Error())
+		return nil, nil, errors.New("ERROR Error Generate Recovery Codes")
+	}
+
+	var hashes []string
+	for _, code := range codes {
+		hashes = append(hashes, u.totp.HashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func (u *UserService) ForgetPasswordWeb(meta audit.Meta, username string) error {
+	user, err := u.data.GetByUsername(username)
+	if err != nil {
+		logrus.Error("Service : Error Get By Username : ", err.Error())
+		return errors.New("ERROR Error Get By Username")
+	}
+
+	code, err := u.otp.GenerateCode()
+	if err != nil {
+		logrus.Error("Service : Error Generate Code Reset : ", err.Error())
+		return errors.New("ERROR Error Generate Code Reset")
+	}
+
+	mail, err := u.codeMail(email.TemplateResetPassword, user.Username, user.Email, user.Language, code)
+	if err != nil {
+		return errors.New("ERROR Sending Email")
+	}
+
+	if err := u.data.InsertCodeReset(user.Username, u.otp.HashCode(user.Username, code), time.Now().Add(u.otp.Expiry()), *mail); err != nil {
+		logrus.Error("Service : Error Insert Code Reset User : ", err.Error())
+		return errors.New("ERROR Error Insert Code Reset User")
+	}
+
+	meta.ActorID = user.ID
+	meta.Actor = user.Username
+	u.audit.Record(meta, audit.ActionPasswordResetRequest, audit.TargetUser, strconv.Itoa(int(user.ID)), nil, nil)
+
+	return nil
+}
+
+func (u *UserService) ResetPassword(meta audit.Meta, username, code, password string) error {
+	record, err := u.data.TakeCodeResetAttempt(username, u.otp.MaxAttempts())
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return errors.New("ERROR Code Not Valid")
+		}
+		logrus.Error("Service : Error Take Code Reset Attempt : ", err.Error())
+		return errors.New("ERROR Error Reset Password")
+	}
+
+	if !u.otp.CompareCode(record.CodeHash, username, code) {
+		return errors.New("ERROR Code Not Valid")
+	}
+
+	hashPassword, err := u.hash.HashPassword(password)
+	if err != nil {
+		logrus.Error("Service : Error Hash Password : ", err.Error())
+		return errors.New("ERROR Error Hashing Password")
+	}
+
+	if err := u.data.ResetPassword(username, record.CodeHash, hashPassword, passwordHistorySize-1); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return errors.New("ERROR Code Not Valid")
+		}
+		logrus.Error("Service : Error Reset Password : ", err.Error())
+		return errors.New("ERROR Error Reset Password")
+	}
+
+	meta.Actor = username
+	if user, err := u.data.GetByUsername(username); err == nil {
+		meta.ActorID = user.ID
+		u.audit.Record(meta, audit.ActionPasswordReset, audit.TargetUser, strconv.Itoa(int(user.ID)), nil, nil)
+	} else {
+		u.audit.Record(meta, audit.ActionPasswordReset, audit.TargetUser, "", nil, map[string]any{"username": username})
+	}
+
+	return nil
+}
+func (u *UserService) codeMail(template, username, address, language, code string) (*outbox.Message, error) {
+	var data = map[string]any{
+		"Username":      username,
+		"Code":          code,
+		"ExpiryMinutes": int(u.otp.Expiry().Minutes()),
+	}
+
+	return u.mail(template, address, language, data)
+}
+
+func (u *UserService) mail(template, address, language string, data map[string]any) (*outbox.Message, error) {
+	content, err := u.email.Render(template, language, data)
+	if err != nil {
+		logrus.Error("Service : Error Render Email : ", err.Error())
+		return nil, err
+	}
+
+	_, sensitive := data["Code"]
+
+	return &outbox.Message{
+		Recipient: address,
+		Subject:   content.Subject,
+		HTML:      content.HTML,
+		Text:      content.Text,
+		Sensitive: sensitive,
+	}, nil
+}
+
+func (u *UserService) GetEmailTemplates() []string {
+	return u.email.Templates()
+}
+
+func (u *UserService) PreviewEmail(name, locale string) (*email.Content, error) {
+	res, err := u.email.Preview(name, locale)
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return nil, errors.New("ERROR Template Not Found")
+		}
+		logrus.Error("Service : Error Preview Email : ", err.Error())
+		return nil, errors.New("ERROR Error Preview Email")
+	}
+
+	return res, nil
+}
+
+func (u *UserService) ChangePassword(meta audit.Meta, userID uint, sessionID, currentPassword, password string) error {
+	user, err := u.data.GetByID(int(userID))
+	if err != nil {
+		logrus.Error("Service : Error Get ByID : ", err.Error())
+		return errors.New("ERROR User Not Found")
+	}
+
+	if err := u.hash.Compare(user.Password, currentPassword); err != nil {
+		if errors.Is(err, enkrip.ErrMismatch) {
+			return errors.New("ERROR Incorrect Password")
+		}
+		logrus.Error("Service : Error Compare Password : ", err.Error())
+		return errors.New("ERROR Error Change Password")
+	}
+
+	history, err := u.data.GetPasswordHistory(user.ID, passwordHistorySize-1)
+	if err != nil {
+		logrus.Error("Service : Error Get Password History : ", err.Error())
+		return errors.New("ERROR Error Change Password")
+	}
+
+	for _, hashed := range append([]string{user.Password}, history...) {
+		if u.hash.Compare(hashed, password) == nil {
+			return errors.New("ERROR Password Recently Used")
+		}
+	}
+
+	hashPassword, err := u.hash.HashPassword(password)
+	if err != nil {
+		logrus.Error("Service : Error Hash Password : ", err.Error())
+		return errors.New("ERROR Error Hashing Password")
+	}
+
+	if err := u.data.ChangePassword(user.ID, user.Password, hashPassword, passwordHistorySize-1); err != nil {
+		logrus.Error("Service : Error Change Password : ", err.Error())
+		return errors.New("ERROR Error Change Password")
+	}
+
+	families, err := u.data.RevokeOtherSessions(user.ID, sessionID)
+	if err != nil {
+		logrus.Error("Service : Error Revoke Other Sessions : ", err.Error())
+		return errors.New("ERROR Error Revoke Sessions")
+	}
+
+	for _, familyID := range families {
+		var revoked = users.RevokedToken{
+			SessionID: familyID,
+			UserID:    user.ID,
+			ExpiredAt: time.Now().Add(jwt.AccessTokenDuration),
+		}
+
+		if err := u.data.InsertRevokedToken(revoked); err != nil {
+			logrus.Error("Service : Error Insert Revoked Token : ", err.Error())
+			return errors.New("ERROR Error Revoke Sessions")
+		}
+		u.deny.add(revoked)
+	}
+
+	u.audit.Record(meta, audit.ActionPasswordChange, audit.TargetUser, strconv.Itoa(int(user.ID)), nil, map[string]any{"revoked_sessions": len(families)})
+
+	return nil
+}
+
+func (u *UserService) UpdateProfile(id int, newData users.UpdateProfile) (*users.ProfileUpdate, error) {
+	user, err := u.data.GetByID(id)
+	if err != nil {
+		logrus.Error("Service : Error Get ByID : ", err.Error())
+		return nil, errors.New("ERROR User Not Found")
+	}
+
+	if newData.Language != "" {
+		newData.Language = email.NormalizeLocale(newData.Language)
+	}
+
+	var language = user.Language
+	if newData.Language != "" {
+		language = newData.Language
+	}
+
+	var result = new(users.ProfileUpdate)
+	var changes []users.ContactChange
+	var mails []outbox.Message
+
+	newData.Username = strings.TrimSpace(newData.Username)
+	newData.Email = strings.ToLower(strings.TrimSpace(newData.Email))
+
+	if newData.Username == user.Username {
+		newData.Username = ""
+	}
+
+	if newData.Username != "" && !strings.EqualFold(newData.Username, user.Username) && !u.data.CheckUsername(newData.Username) {
+		logrus.Error("Service : Username already registered")
+		return nil, errors.New("ERROR Username already registered")
+	}
+
+	if newData.Email != "" && !strings.EqualFold(newData.Email, user.Email) {
+		if !u.data.CheckEmail(newData.Email) {
+			logrus.Error("Service : Email already registered")
+			return nil, errors.New("ERROR Email already registered")
+		}
+
+		change, mail, err := u.contactChange(user, users.ContactKindEmail, newData.Email, newData.Email, language, email.TemplateEmailChange)
+		if err != nil {
+			return nil, errors.New("ERROR Error Update Profile")
+		}
+
+		notice, err := u.mail(email.TemplateEmailNotice, user.Email, language, map[string]any{
+			"Username": user.Username,
+			"Value":    newData.Email,
+		})
+		if err != nil {
+			return nil, errors.New("ERROR Error Update Profile")
+		}
+
+		changes = append(changes, *change)
+		mails = append(mails, *mail, *notice)
+		result.PendingEmail = newData.Email
+	}
+	newData.Email = ""
+
+	if newData.PhoneNumber == user.PhoneNumber {
+		newData.PhoneNumber = ""
+	}
+
+	if newData.PhoneNumber != "" && u.phoneConfirm {
+		change, mail, err := u.contactChange(user, users.ContactKindPhone, newData.PhoneNumber, user.Email, language, email.TemplatePhoneChange)
+		if err != nil {
+			return nil, errors.New("ERROR Error Update Profile")
+		}
+
+		changes = append(changes, *change)
+		mails = append(mails, *mail)
+		result.PendingPhoneNumber = newData.PhoneNumber
+		newData.PhoneNumber = ""
+	}
+
+	if _, err := u.data.UpdateProfile(id, newData, changes, mails); err != nil {
+		if strings.Contains(err.Error(), "already registered") {
+			return nil, err
+		}
+		logrus.Error("Service : Error Update Profile : ", err.Error())
+		return nil, errors.New("ERROR Error Update Profile")
+	}
+
+	return result, nil
+}
+
+func (u *UserService) contactChange(user users.User, kind, value, address, language, template string) (*users.ContactChange, *outbox.Message, error) {
+	code, err := u.otp.GenerateCode()
+	if err != nil {
+		logrus.Error("Service : Error Generate Code Contact Change : ", err.Error())
+		return nil, nil, err
+	}
+
+	mail, err := u.mail(template, address, language, map[string]any{
+		"Username":      user.Username,
+		"Code":          code,
+		"ExpiryMinutes": int(u.otp.Expiry().Minutes()),
+		"Value":         value,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var change = &users.ContactChange{
+		UserID:    user.ID,
+		Kind:      kind,
+		Value:     value,
+		CodeHash:  u.otp.HashCode(contactChangeKey(user.ID, kind), code),
+		ExpiredAt: time.Now().Add(u.otp.Expiry()),
+	}
+
+	return change, mail, nil
+}
+
+func contactChangeKey(userID uint, kind string) string {
+	return kind + ":" + strconv.FormatUint(uint64(userID), 10)
+}
+
+func (u *UserService) ConfirmContactChange(userID uint, kind, code string) error {
+	record, err := u.data.TakeContactChangeAttempt(userID, kind, u.otp.MaxAttempts())
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return errors.New("ERROR Code Not Valid")
+		}
+		logrus.Error("Service : Error Take Contact Change Attempt : ", err.Error())
+		return errors.New("ERROR Error Confirm Contact Change")
+	}
+
+	if !u.otp.CompareCode(record.CodeHash, contactChangeKey(userID, kind), code) {
+		return errors.New("ERROR Code Not Valid")
+	}
+
+	if err := u.data.ApplyContactChange(userID, kind, record.CodeHash); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return errors.New("ERROR Code Not Valid")
+		}
+		if strings.Contains(err.Error(), "already registered") {
+			return errors.New("ERROR Email already registered")
+		}
+		logrus.Error("Service : Error Apply Contact Change : ", err.Error())
+		return errors.New("ERROR Error Confirm Contact Change")
+	}
+
+	return nil
+}
+
+func (u *UserService) Profile(id int) (*users.User, error) {
+	res, err := u.data.GetByID(id)
+	if err != nil {
+		logrus.Error("Service : Error Get ByID : ", err.Error())
+		return nil, errors.New("ERROR Error Get ByID")
+	}
+	return &res, nil
+}
+
+func (u *UserService) GetUsers(query users.UserQuery) (*users.UserPage, error) {
+	res, err := u.data.GetUsers(query)
+	if err != nil {
+		if strings.Contains(err.Error(), "Invalid Cursor") {
+			return nil, err
+		}
+		logrus.Error("Service : Error Get Users : ", err.Error())
+		return nil, errors.New("ERROR Error Get Users")
+	}
+
+	return res, nil
+}
+func (u *UserService) ExportUsers(meta audit.Meta, query users.UserQuery, format string, columns []string, w io.Writer) error {
+	var write func(users.UserSummary) error
+	var flush = func() error { return nil }
+
+	if format == users.ExportFormatNDJSON {
+		var encoder = json.NewEncoder(w)
+		write = func(user users.UserSummary) error {
+			var row = map[string]any{}
+			for _, column := range columns {
+				row[column] = exportValue(user, column)
+			}
+			return encoder.Encode(row)
+		}
+	} else {
+		var writer = csv.NewWriter(w)
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+		write = func(user users.UserSummary) error {
+			var row = make([]string, len(columns))
+			for i, column := range columns {
+				row[i] = helper.CSVCell(exportCell(exportValue(user, column)))
+			}
+			return writer.Write(row)
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	}
+
+	var exported int
+	err := u.data.ExportUsers(query, func(summaries []users.UserSummary) error {
+		for _, val := range summaries {
+			if err := write(val); err != nil {
+				return err
+			}
+			exported++
+		}
+		return flush()
+	})
+	if err != nil {
+		logrus.Error("Service : Error Export Users : ", err.Error())
+		return errors.New("ERROR Error Export Users")
+	}
+
+	u.audit.Record(meta, audit.ActionUserExport, audit.TargetUser, "", nil, map[string]any{
+		"format":  format,
+		"columns": columns,
+		"rows":    exported,
+	})
+
+	return flush()
+}
+
+func exportValue(user users.UserSummary, column string) any {
+	switch column {
+	case "id":
+		return user.ID
+	case "username":
+		return user.Username
+	case "email":
+		return user.Email
+	case "phone_number":
+		return user.PhoneNumber
+	case "status":
+		return user.Status
+	case "language":
+		return user.Language
+	case "roles":
+		return user.Roles
+	case "created_at":
+		return user.CreatedAt
+	case "deleted_at":
+		return user.DeletedAt
+	}
+	return nil
+}
+
+func exportCell(value any) string {
+	switch val := value.(type) {
+	case uint:
+		return strconv.FormatUint(uint64(val), 10)
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case []string:
+		return strings.Join(val, "|")
+	case time.Time:
+		return val.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return val.UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
+func (u *UserService) ImportUsers(meta audit.Meta, rows []users.ImportRow, options users.ImportOptions) (*users.ImportReport, error) {
+	var report = &users.ImportReport{
+		DryRun: options.DryRun,
+		Invite: options.Invite,
+		Total:  len(rows),
+		Errors: []users.ImportRowError{},
+	}
+
+	var usernames = map[string]int{}
+	var emails = map[string]int{}
+
+	for i := range rows {
+		var row = &rows[i]
+		row.Username = strings.TrimSpace(row.Username)
+		row.Email = strings.ToLower(strings.TrimSpace(row.Email))
+		row.Language = email.NormalizeLocale(row.Language)
+
+		if line, found := usernames[strings.ToLower(row.Username)]; found && row.Username != "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("Username duplicates line %d", line))
+		} else {
+			usernames[strings.ToLower(row.Username)] = row.Line
+		}
+
+		if line, found := emails[row.Email]; found && row.Email != "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("Email duplicates line %d", line))
+		} else {
+			emails[row.Email] = row.Line
+		}
+
+		if len(row.Errors) > 0 {
+			continue
+		}
+
+		if !u.data.CheckUsername(row.Username) {
+			row.Errors = append(row.Errors, "Username already registered")
+		}
+		if !u.data.CheckEmail(row.Email) {
+			row.Errors = append(row.Errors, "Email already registered")
+		}
+	}
+
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			report.Failed++
+			report.Errors = append(report.Errors, users.ImportRowError{Line: row.Line, Username: row.Username, Errors: row.Errors})
+			continue
+		}
+
+		report.Valid++
+		if options.DryRun {
+			continue
+		}
+
+		if err := u.importUser(meta, row, options.Invite); err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, users.ImportRowError{Line: row.Line, Username: row.Username, Errors: []string{strings.TrimPrefix(err.Error(), "ERROR ")}})
+			continue
+		}
+		report.Created++
+	}
+
+	if !options.DryRun {
+		u.audit.Record(meta, audit.ActionUserImport, audit.TargetUser, "", nil, map[string]any{
+			"total":   report.Total,
+			"created": report.Created,
+			"failed":  report.Failed,
+			"invite":  options.Invite,
+		})
+	}
+
+	return report, nil
+}
+
+func (u *UserService) importUser(meta audit.Meta, row users.ImportRow, invite bool) error {
+	var password = row.Password
+	if invite {
+		password = jwt.GenerateRandomToken(32)
+	}
+
+	hashPassword, err := u.hash.HashPassword(password)
+	if err != nil {
+		logrus.Error("Service : Error Hash Password : ", err.Error())
+		return errors.New("ERROR Error Hashing Password")
+	}
+
+	var newData = users.User{
+		Username:    row.Username,
+		Email:       row.Email,
+		PhoneNumber: row.PhoneNumber,
+		Password:    hashPassword,
+		Language:    row.Language,
+	}
+
+	var reset *users.UserResetPass
+	var mail *outbox.Message
+
+	if invite {
+		code, err := u.otp.GenerateCode()
+		if err != nil {
+			logrus.Error("Service : Error Generate Invitation Code : ", err.Error())
+			return errors.New("ERROR Error Generate Invitation Code")
+		}
+
+		mail, err = u.mail(email.TemplateInvitation, row.Email, row.Language, map[string]any{
+			"Username":    row.Username,
+			"Code":        code,
+			"ExpiryHours": int(invitationExpiry.Hours()),
+		})
+		if err != nil {
+			return errors.New("ERROR Error Render Invitation Email")
+		}
+
+		reset = &users.UserResetPass{
+			Username:  row.Username,
+			CodeHash:  u.otp.HashCode(row.Username, code),
+			ExpiredAt: time.Now().Add(invitationExpiry),
+		}
+	}
+
+	result, err := u.data.ImportUser(newData, roles.RoleCustomer, reset, mail)
+	if err != nil {
+		if strings.Contains(err.Error(), "already registered") {
+			return err
+		}
+		logrus.Error("Service : Error Import User : ", err.Error())
+		return errors.New("ERROR Error Create User")
+	}
+
+	u.recordDefaultRole(meta, result.ID)
+
+	return nil
+}
+
+func (u *UserService) recordDefaultRole(meta audit.Meta, userID uint) {
+	u.audit.Record(meta, audit.ActionRoleAssign, audit.TargetUser, strconv.Itoa(int(userID)),
+		map[string]any{"roles": []string{}},
+		map[string]any{"roles": []string{roles.RoleCustomer}, "role": roles.RoleCustomer})
+}
+
+func (u *UserService) Activate(meta audit.Meta, id int) (bool, error) {
+	before, _ := u.data.GetStatus(id)
+
+	res, err := u.data.Activate(id)
+	if err != nil {
+		logrus.Error("Service : Error Activate : ", err.Error())
+		return false, errors.New("ERROR Error Activate")
+	}
+
+	u.audit.Record(meta, audit.ActionUserActivate, audit.TargetUser, strconv.Itoa(id), map[string]any{"status": before}, map[string]any{"status": true})
+	return res, nil
+}
+func (u *UserService) Deactivate(meta audit.Meta, id int) (bool, error) {
+	before, _ := u.data.GetStatus(id)
+
+	res, err := u.data.Deactivate(id)
+	if err != nil {
+		logrus.Error("Service : Error Deactivate : ", err.Error())
+		return false, errors.New("ERROR Error Deactivate")
+	}
+
+	if err := u.LogoutAll(uint(id)); err != nil {
+		logrus.Error("Service : Error Revoke Deactivated User Sessions : ", err.Error())
+		return false, errors.New("ERROR Error Deactivate")
+	}
+
+	u.audit.Record(meta, audit.ActionUserDeactivate, audit.TargetUser, strconv.Itoa(id), map[string]any{"status": before}, map[string]any{"status": false})
+	return res, nil
+}
+
+func (u *UserService) Unlock(meta audit.Meta, id int) error {
+	user, err := u.data.GetAccountByID(id)
+	if err != nil {
+		logrus.Error("Service : Error Get Account ByID : ", err.Error())
+		return errors.New("ERROR User Not Found")
+	}
+
+	if err := u.data.ResetLoginAttempt(loginKindAccount, strings.ToLower(user.Username)); err != nil {
+		logrus.Error("Service : Error Reset Login Attempt : ", err.Error())
+		return errors.New("ERROR Error Unlock")
+	}
+
+	if err := u.data.ResetMFAFailure(user.ID); err != nil {
+		logrus.Error("Service : Error Reset MFA Failure : ", err.Error())
+	}
+
+	var event = users.LockoutEvent{
+		UserID:   user.ID,
+		Username: user.Username,
+		Event:    lockoutEventUnlocked,
+		Reason:   "unlocked by administrator",
+		ActorID:  meta.ActorID,
+	}
+
+	if err := u.data.InsertLockoutEvent(event); err != nil {
+		logrus.Error("Service : Error Insert Lockout Event : ", err.Error())
+	}
+
+	u.audit.Record(meta, audit.ActionUserUnlock, audit.TargetUser, strconv.Itoa(id), nil, nil)
+
+	return nil
+}
+
+func (u *UserService) DeleteAccount(meta audit.Meta, userID uint, password string) (*time.Time, error) {
+	user, err := u.data.GetByID(int(userID))
+	if err != nil {
+		logrus.Error("Service : Error Get ByID : ", err.Error())
+		return nil, errors.New("ERROR User Not Found")
+	}
+
+	if err := u.hash.Compare(user.Password, password); err != nil {
+		if errors.Is(err, enkrip.ErrMismatch) {
+			return nil, errors.New("ERROR Incorrect Password")
+		}
+		logrus.Error("Service : Error Compare Password : ", err.Error())
+		return nil, errors.New("ERROR Error Delete Account")
+	}
+
+	if err := u.deleteUser(user.ID); err != nil {
+		return nil, errors.New("ERROR Error Delete Account")
+	}
+
+	var purgeAfter = time.Now().Add(u.deletionGrace)
+	u.audit.Record(meta, audit.ActionAccountDelete, audit.TargetUser, strconv.Itoa(int(user.ID)), nil, map[string]any{"purge_after": purgeAfter})
+	return &purgeAfter, nil
+}
+
+func (u *UserService) DeleteUser(meta audit.Meta, id int) error {
+	if err := u.deleteUser(uint(id)); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return errors.New("ERROR User Not Found")
+		}
+		return errors.New("ERROR Error Delete User")
+	}
+
+	u.audit.Record(meta, audit.ActionUserDelete, audit.TargetUser, strconv.Itoa(id), map[string]any{"deleted": false}, map[string]any{"deleted": true})
+
+	return nil
+}
+
+func (u *UserService) deleteUser(id uint) error {
+	if err := u.data.DeleteUser(id); err != nil {
+		logrus.Error("Service : Error Delete User : ", err.Error())
+		return err
+	}
+
+	if err := u.LogoutAll(id); err != nil {
+		logrus.Error("Service : Error Revoke Deleted User Sessions : ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (u *UserService) RestoreUser(meta audit.Meta, id int) error {
+	if err := u.data.RestoreUser(uint(id)); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return errors.New("ERROR User Not Found")
+		}
+		logrus.Error("Service : Error Restore User : ", err.Error())
+		return errors.New("ERROR Error Restore User")
+	}
+
+	u.audit.Record(meta, audit.ActionUserRestore, audit.TargetUser, strconv.Itoa(id), map[string]any{"deleted": true}, map[string]any{"deleted": false})
+
+	return nil
+}
+
+func (u *UserService) PurgeDeletedUsers() error {
+	res, avatars, err := u.data.PurgeDeletedUsers(time.Now().Add(-u.deletionGrace), purgeBatchSize)
+	if res > 0 {
+		logrus.Info("Service : Purged Deleted Users : ", res)
+	}
+	for _, key := range avatars {
+		u.deleteAvatar(key)
+	}
+	if err != nil {
+		logrus.Error("Service : Error Purge Deleted Users : ", err.Error())
+		return errors.New("ERROR Error Purge Deleted Users")
+	}
+
+	return nil
+}
+
+func (u *UserService) UserDashboard(query users.DashboardQuery) (users.UserDashboard, error) {
+	if !query.From.Before(query.To) {
+		return users.UserDashboard{}, errors.New("ERROR Invalid Date Range")
+	}
+
+	var days = query.To.Sub(query.From).Hours() / 24
+	var points = map[string]float64{
+		users.GranularityDay:   days,
+		users.GranularityWeek:  days / 7,
+		users.GranularityMonth: days / 28,
+	}[query.Granularity]
+	if points > dashboardMaxPoints {
+		return users.UserDashboard{}, errors.New("ERROR Date Range Too Large")
+	}
+
+	res, err := u.data.UserDashboard(query)
+	if err != nil {
+		logrus.Error("Service : Error User Dashboard : ", err.Error())
+		return res, errors.New("ERROR Error User Dashboard")
+	}
+
+	if res.TotalNewUser > 0 {
+		res.ConversionRate = math.Round(float64(res.TotalNewVerified)/float64(res.TotalNewUser)*10000) / 100
+	}
+
+	return res, nil
+}
+func (u *UserService) UserVerificationCode(username, address, language string) error {
+	code, err := u.otp.GenerateCode()
+	if err != nil {
+		logrus.Error("Service : Error Generate Code Verification : ", err.Error())
+		return errors.New("ERROR Error Generate Code Verification")
+	}
+
+	mail, err := u.codeMail(email.TemplateVerification, username, address, language, code)
+	if err != nil {
+		return errors.New("ERROR Send Email Verification")
+	}
+
+	if err := u.data.InsertCodeVerification(username, u.otp.HashCode(username, code), time.Now().Add(u.otp.Expiry()), *mail); err != nil {
+		logrus.Error("Service : Error Insert Code Verification : ", err.Error())
+		return errors.New("ERROR Error Insert Code Verification")
+	}
+
+	return nil
+}
+
+func (u *UserService) ResendVerification(identifier string) error {
+	user, err := u.data.GetUnverifiedUser(identifier)
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return nil
+		}
+		logrus.Error("Service : Error Get Unverified User : ", err.Error())
+		return errors.New("ERROR Error Resend Verification")
+	}
+
+	requests, err := u.data.GetVerificationRequests(user.Username, time.Now().Add(-time.Hour*24))
+	if err != nil {
+		logrus.Error("Service : Error Get Verification Requests : ", err.Error())
+		return errors.New("ERROR Error Resend Verification")
+	}
+
+	if len(requests) >= verificationDailyCap {
+		logrus.Info("Service : Verification Daily Cap Reached : ", user.Username)
+		return nil
+	}
+
+	if len(requests) > 0 && time.Since(requests[0]) < verificationResendCooldown {
+		logrus.Info("Service : Verification Resend Cooldown : ", user.Username)
+		return nil
+	}
+
+	return u.UserVerificationCode(user.Username, user.Email, user.Language)
+}
+
+func (u *UserService) UserVerification(username, code string) error {
+	record, err := u.data.TakeCodeVerificationAttempt(username, u.otp.MaxAttempts())
+	if err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return errors.New("ERROR Code Not Valid")
+		}
+		logrus.Error("Service : Error Take Code Verification Attempt : ", err.Error())
+		return errors.New("ERROR Error User Verification")
+	}
+
+	if !u.otp.CompareCode(record.CodeHash, username, code) {
+		return errors.New("ERROR Code Not Valid")
+	}
+
+	if err := u.data.UserVerification(username, record.CodeHash); err != nil {
+		if strings.Contains(err.Error(), "Not Found") {
+			return errors.New("ERROR Code Not Valid")
+		}
+		logrus.Error("Service : Error User Verification : ", err.Error())
+		return errors.New("ERROR Error User Verification")
+	}
+
+	return nil
 }
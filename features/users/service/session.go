@@ -0,0 +1,38 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+const sessionTouchInterval = time.Minute * 5
+
+type sessionTouches struct {
+	mu      sync.Mutex
+	touched map[string]time.Time
+}
+
+func newSessionTouches() *sessionTouches {
+	return &sessionTouches{
+		touched: map[string]time.Time{},
+	}
+}
+
+func (s *sessionTouches) due(sessionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var now = time.Now()
+	if last, found := s.touched[sessionID]; found && now.Sub(last) < sessionTouchInterval {
+		return false
+	}
+
+	for id, last := range s.touched {
+		if now.Sub(last) >= sessionTouchInterval {
+			delete(s.touched, id)
+		}
+	}
+
+	s.touched[sessionID] = now
+	return true
+}
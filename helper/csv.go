@@ -0,0 +1,14 @@
+package helper
+
+func CSVCell(val string) string {
+	if val == "" {
+		return val
+	}
+
+	switch val[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + val
+	}
+
+	return val
+}
@@ -0,0 +1,115 @@
+package email
+
+import (
+	"e-ticketing-gin/configs"
+	"strings"
+	"testing"
+)
+
+func TestNewTransport(t *testing.T) {
+	var tests = []struct {
+		driver  string
+		wantErr bool
+	}{
+		{driver: "memory"},
+		{driver: "file"},
+		{driver: "smtp"},
+		{driver: "sendmail", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			transport, err := NewTransport(&configs.ProgramConfig{MailDriver: tt.driver, MailOutboxDir: t.TempDir()})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTransport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && transport == nil {
+				t.Fatal("NewTransport() returned nil transport")
+			}
+		})
+	}
+}
+
+func TestSendEmailWithMemoryTransport(t *testing.T) {
+	var transport = NewMemoryTransport()
+	var mail = NewEmailWithTransport(&configs.ProgramConfig{MailFrom: "no-reply@example.com"}, transport)
+
+	content, err := mail.Render(TemplateVerification, "en-US", map[string]any{
+		"Username":      "<b>johndoe</b>",
+		"Code":          "482913",
+		"ExpiryMinutes": 10,
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	if err := mail.SendEmail("john@example.com", *content); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+
+	var messages = transport.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Messages() = %d, want 1", len(messages))
+	}
+
+	var message = messages[0]
+	if message.From != "no-reply@example.com" || len(message.To) != 1 || message.To[0] != "john@example.com" {
+		t.Errorf("envelope = %s -> %v", message.From, message.To)
+	}
+
+	var raw = string(message.Raw)
+	for _, want := range []string{"Subject: Verify Your Account - Your OTP Code", "482913", "text/plain", "text/html"} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("message does not contain %q", want)
+		}
+	}
+
+	transport.Reset()
+	if len(transport.Messages()) != 0 {
+		t.Error("Reset() did not clear messages")
+	}
+}
+
+func TestRender(t *testing.T) {
+	var mail = NewEmailWithTransport(&configs.ProgramConfig{}, NewMemoryTransport())
+
+	var tests = []struct {
+		name    string
+		locale  string
+		wantErr bool
+	}{
+		{name: TemplateResetPassword, locale: "en"},
+		{name: TemplateInvitation, locale: "id-ID"},
+		{name: TemplateEmailNotice, locale: "fr"},
+		{name: "unknown", locale: "en", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/"+tt.locale, func(t *testing.T) {
+			content, err := mail.Render(tt.name, tt.locale, SampleData(tt.name))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if content.Subject == "" || content.HTML == "" || content.Text == "" {
+				t.Errorf("Render() = %+v, want subject, html and text", content)
+			}
+			if strings.Contains(content.HTML, "<b>johndoe</b>") || !strings.Contains(content.HTML, "&lt;b&gt;johndoe&lt;/b&gt;") {
+				t.Error("Render() html does not escape user input")
+			}
+			if !strings.Contains(content.Text, "<b>johndoe</b>") {
+				t.Errorf("Render() text = %q, want the username verbatim", content.Text)
+			}
+		})
+	}
+
+	for _, name := range Templates() {
+		for _, locale := range Locales {
+			if _, err := mail.Preview(name, locale); err != nil {
+				t.Errorf("Preview(%s, %s) error = %v", name, locale, err)
+			}
+		}
+	}
+}
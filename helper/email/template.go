@@ -0,0 +1,209 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"golang.org/x/net/html"
+	htmlTemplate "html/template"
+	"sort"
+	"strings"
+)
+
+const (
+	TemplateResetPassword = "reset_password"
+	TemplateVerification  = "verification"
+	TemplateEmailChange   = "email_change"
+	TemplateEmailNotice   = "email_change_notice"
+	TemplatePhoneChange   = "phone_change"
+	TemplateInvitation    = "invitation"
+
+	LocaleID      = "id"
+	LocaleEN      = "en"
+	DefaultLocale = LocaleID
+)
+
+var Locales = []string{LocaleID, LocaleEN}
+
+var templateNames = []string{TemplateResetPassword, TemplateVerification, TemplateEmailChange, TemplateEmailNotice, TemplatePhoneChange, TemplateInvitation}
+
+//go:embed templates
+var templateFS embed.FS
+
+type Content struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+type catalog map[string]*htmlTemplate.Template
+
+func loadCatalog() (catalog, error) {
+	var result = catalog{}
+
+	for _, locale := range Locales {
+		for _, name := range templateNames {
+			tmpl, err := htmlTemplate.ParseFS(templateFS, "templates/layouts/base.html", "templates/"+locale+"/"+name+".html")
+			if err != nil {
+				return nil, err
+			}
+			result[catalogKey(locale, name)] = tmpl
+		}
+	}
+
+	return result, nil
+}
+
+func catalogKey(locale, name string) string {
+	return locale + "/" + name
+}
+
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		locale = locale[:i]
+	}
+
+	for _, val := range Locales {
+		if val == locale {
+			return locale
+		}
+	}
+
+	return DefaultLocale
+}
+
+func Templates() []string {
+	var result = append([]string(nil), templateNames...)
+	sort.Strings(result)
+	return result
+}
+
+func SampleData(name string) map[string]any {
+	switch name {
+	case TemplateResetPassword, TemplateVerification:
+		return map[string]any{
+			"Username":      "<b>johndoe</b>",
+			"Code":          "123456",
+			"ExpiryMinutes": 10,
+		}
+	case TemplateEmailChange:
+		return map[string]any{
+			"Username":      "<b>johndoe</b>",
+			"Code":          "123456",
+			"ExpiryMinutes": 10,
+			"Value":         "john.doe@example.com",
+		}
+	case TemplateEmailNotice:
+		return map[string]any{
+			"Username": "<b>johndoe</b>",
+			"Value":    "john.doe@example.com",
+		}
+	case TemplatePhoneChange:
+		return map[string]any{
+			"Username":      "<b>johndoe</b>",
+			"Code":          "123456",
+			"ExpiryMinutes": 10,
+			"Value":         "081234567890",
+		}
+	case TemplateInvitation:
+		return map[string]any{
+			"Username":    "<b>johndoe</b>",
+			"Code":        "123456",
+			"ExpiryHours": 72,
+		}
+	}
+	return map[string]any{}
+}
+
+func (c catalog) render(name, locale string, data map[string]any) (*Content, error) {
+	locale = NormalizeLocale(locale)
+
+	tmpl, found := c[catalogKey(locale, name)]
+	if !found {
+		return nil, fmt.Errorf("EMAIL : Template Not Found : %s", name)
+	}
+
+	var values = map[string]any{}
+	for key, val := range data {
+		values[key] = val
+	}
+	values["Locale"] = locale
+
+	var subject bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", values); err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&body, "base.html", values); err != nil {
+		return nil, err
+	}
+
+	return &Content{
+		Subject: strings.TrimSpace(html.UnescapeString(subject.String())),
+		HTML:    body.String(),
+		Text:    htmlToText(body.String()),
+	}, nil
+}
+
+func htmlToText(source string) string {
+	var tokenizer = html.NewTokenizer(strings.NewReader(source))
+	var builder strings.Builder
+	var skip = 0
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return collapseLines(builder.String())
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "head", "style", "script":
+				skip++
+			case "br":
+				builder.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "head", "style", "script":
+				if skip > 0 {
+					skip--
+				}
+			case "p", "tr", "div", "h1", "h2", "h3", "li":
+				builder.WriteString("\n\n")
+			}
+		case html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "br" {
+				builder.WriteString("\n")
+			}
+		case html.TextToken:
+			if skip == 0 {
+				builder.WriteString(strings.Join(strings.Fields(string(tokenizer.Text())), " "))
+				builder.WriteString(" ")
+			}
+		}
+	}
+}
+
+func collapseLines(text string) string {
+	var lines []string
+	var blank = true
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		lines = append(lines, line)
+		blank = false
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,216 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"e-ticketing-gin/configs"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gopkg.in/gomail.v2"
+	"io"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Transport interface {
+	gomail.Sender
+}
+
+func NewTransport(c *configs.ProgramConfig) (Transport, error) {
+	switch c.MailDriver {
+	case "smtp":
+		return NewSMTPTransport(c), nil
+	case "file":
+		return NewFileTransport(c.MailOutboxDir)
+	case "memory":
+		return NewMemoryTransport(), nil
+	}
+	return nil, fmt.Errorf("EMAIL : Unsupported Mail Driver : %s", c.MailDriver)
+}
+
+type SMTPTransport struct {
+	host     string
+	port     int
+	tlsMode  string
+	username string
+	password string
+}
+
+func NewSMTPTransport(c *configs.ProgramConfig) *SMTPTransport {
+	return &SMTPTransport{
+		host:     c.SMTPHost,
+		port:     c.SMTPPort,
+		tlsMode:  c.SMTPTLS,
+		username: c.SMTPUsername,
+		password: c.SMTPPassword,
+	}
+}
+
+func (t *SMTPTransport) Send(from string, to []string, msg io.WriterTo) error {
+	client, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if t.tlsMode == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("EMAIL : SMTP Server Does Not Support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+			return err
+		}
+	}
+
+	if t.username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(smtp.PlainAuth("", t.username, t.password, t.host)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := msg.WriteTo(writer); err != nil {
+		writer.Close()
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (t *SMTPTransport) dial() (*smtp.Client, error) {
+	var addr = net.JoinHostPort(t.host, strconv.Itoa(t.port))
+
+	if t.tlsMode == "tls" {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second * 10}, "tcp", addr, &tls.Config{ServerName: t.host})
+		if err != nil {
+			return nil, err
+		}
+		return smtp.NewClient(conn, t.host)
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, time.Second*10)
+	if err != nil {
+		return nil, err
+	}
+	return smtp.NewClient(conn, t.host)
+}
+
+type FileTransport struct {
+	dir string
+}
+
+func NewFileTransport(dir string) (*FileTransport, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileTransport{
+		dir: dir,
+	}, nil
+}
+
+func (t *FileTransport) Send(from string, to []string, msg io.WriterTo) error {
+	var suffix = make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+
+	var name = fmt.Sprintf("%d.%s.eml", time.Now().UnixNano(), hex.EncodeToString(suffix))
+	var tmpPath = filepath.Join(t.dir, "tmp", name)
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := msg.WriteTo(file); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, filepath.Join(t.dir, "new", name))
+}
+
+type SentMessage struct {
+	From   string
+	To     []string
+	Raw    []byte
+	SentAt time.Time
+}
+
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []SentMessage
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Send(from string, to []string, msg io.WriterTo) error {
+	var buf bytes.Buffer
+	if _, err := msg.WriteTo(&buf); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = append(t.messages, SentMessage{
+		From:   from,
+		To:     append([]string(nil), to...),
+		Raw:    buf.Bytes(),
+		SentAt: time.Now(),
+	})
+
+	return nil
+}
+
+func (t *MemoryTransport) Messages() []SentMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]SentMessage(nil), t.messages...)
+}
+
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = nil
+}
@@ -1,23 +1,190 @@
 package enkrip
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"e-ticketing-gin/configs"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	ErrMismatch      = errors.New("ERROR Password Mismatch")
+	ErrInvalidHash   = errors.New("ERROR Invalid Password Hash")
+	ErrIncompatible  = errors.New("ERROR Incompatible Argon2 Version")
+	ErrUnknownFormat = errors.New("ERROR Unknown Password Hash Format")
+)
 
 type HashInterface interface {
 	Compare(hashed, input string) error
 	HashPassword(input string) (string, error)
+	NeedsRehash(hashed string) bool
 }
 
-type Hash struct{}
+type Params struct {
+	Algorithm   string
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+	BcryptCost  int
+}
 
-func New() HashInterface { return &Hash{} }
+func DefaultParams() Params {
+	return Params{
+		Algorithm:   AlgorithmArgon2id,
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		BcryptCost:  bcrypt.DefaultCost,
+	}
+}
+
+type Hash struct {
+	params Params
+}
+
+func New(c *configs.ProgramConfig) HashInterface {
+	var params = DefaultParams()
+	params.Algorithm = c.HashAlgorithm
+	params.Memory = uint32(c.Argon2Memory)
+	params.Iterations = uint32(c.Argon2Iterations)
+	params.Parallelism = uint8(c.Argon2Parallelism)
+	params.BcryptCost = c.BcryptCost
+
+	return NewWithParams(params)
+}
+
+func NewWithParams(params Params) HashInterface {
+	return &Hash{
+		params: params,
+	}
+}
 
 func (h *Hash) Compare(hashed, input string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(input))
+	switch {
+	case strings.HasPrefix(hashed, "$argon2id$"):
+		return compareArgon2id(hashed, input)
+	case isBcrypt(hashed):
+		if err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(input)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return ErrMismatch
+			}
+			return err
+		}
+		return nil
+	}
+	return ErrUnknownFormat
 }
+
 func (h *Hash) HashPassword(input string) (string, error) {
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(input), 10)
+	if h.params.Algorithm == AlgorithmBcrypt {
+		hashPassword, err := bcrypt.GenerateFromPassword([]byte(input), h.params.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashPassword), nil
+	}
+
+	var salt = make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	var key = argon2.IDKey([]byte(input), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Hash) NeedsRehash(hashed string) bool {
+	if h.params.Algorithm == AlgorithmBcrypt {
+		if !isBcrypt(hashed) {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(hashed))
+		return err != nil || cost != h.params.BcryptCost
+	}
+
+	params, _, _, err := decodeArgon2id(hashed)
 	if err != nil {
-		return "", nil
+		return true
 	}
-	return string(hashPassword), nil
+
+	return params.Memory != h.params.Memory ||
+		params.Iterations != h.params.Iterations ||
+		params.Parallelism != h.params.Parallelism ||
+		params.SaltLength != h.params.SaltLength ||
+		params.KeyLength != h.params.KeyLength
+}
+
+func isBcrypt(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") || strings.HasPrefix(hashed, "$2b$") || strings.HasPrefix(hashed, "$2y$")
+}
+
+func compareArgon2id(hashed, input string) error {
+	params, salt, key, err := decodeArgon2id(hashed)
+	if err != nil {
+		return err
+	}
+
+	var other = argon2.IDKey([]byte(input), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+func decodeArgon2id(hashed string) (*Params, []byte, []byte, error) {
+	var parts = strings.Split(hashed, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, ErrIncompatible
+	}
+
+	var params = new(Params)
+	params.Algorithm = AlgorithmArgon2id
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	params.SaltLength = uint32(len(salt))
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
 }
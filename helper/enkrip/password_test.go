@@ -0,0 +1,127 @@
+package enkrip
+
+import (
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+)
+
+func testParams() Params {
+	var params = DefaultParams()
+	params.Memory = 1024
+	params.Iterations = 1
+	params.Parallelism = 1
+	params.BcryptCost = bcrypt.MinCost
+	return params
+}
+
+func TestHashPasswordArgon2id(t *testing.T) {
+	var hash = NewWithParams(testParams())
+
+	hashed, err := hash.HashPassword("Tr4in-Ticket")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if !strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("HashPassword() = %s, want argon2id PHC string", hashed)
+	}
+
+	other, _ := hash.HashPassword("Tr4in-Ticket")
+	if other == hashed {
+		t.Error("HashPassword() reused a salt")
+	}
+
+	params, salt, key, err := decodeArgon2id(hashed)
+	if err != nil {
+		t.Fatalf("decodeArgon2id() error = %v", err)
+	}
+	if params.Memory != 1024 || params.Iterations != 1 || params.Parallelism != 1 || len(salt) != 16 || len(key) != 32 {
+		t.Errorf("decodeArgon2id() = %+v, salt %d, key %d", *params, len(salt), len(key))
+	}
+}
+
+func TestCompare(t *testing.T) {
+	var argon = NewWithParams(testParams())
+	argonHash, _ := argon.HashPassword("Tr4in-Ticket")
+
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("Tr4in-Ticket"), bcrypt.MinCost)
+	var bcrypt2y = "$2y$" + strings.TrimPrefix(string(bcryptHash), "$2a$")
+
+	var tests = []struct {
+		name    string
+		hashed  string
+		input   string
+		wantErr error
+	}{
+		{name: "argon2id match", hashed: argonHash, input: "Tr4in-Ticket"},
+		{name: "argon2id mismatch", hashed: argonHash, input: "tr4in-ticket", wantErr: ErrMismatch},
+		{name: "bcrypt match", hashed: string(bcryptHash), input: "Tr4in-Ticket"},
+		{name: "bcrypt 2y match", hashed: bcrypt2y, input: "Tr4in-Ticket"},
+		{name: "bcrypt mismatch", hashed: string(bcryptHash), input: "wrong", wantErr: ErrMismatch},
+		{name: "unknown format", hashed: "plaintext", input: "plaintext", wantErr: ErrUnknownFormat},
+		{name: "argon2i is not accepted", hashed: "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5", input: "x", wantErr: ErrUnknownFormat},
+		{name: "incompatible version", hashed: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5", input: "x", wantErr: ErrIncompatible},
+		{name: "missing segment", hashed: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA", input: "x", wantErr: ErrInvalidHash},
+		{name: "malformed parameters", hashed: "$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5", input: "x", wantErr: ErrInvalidHash},
+		{name: "malformed version", hashed: "$argon2id$version$m=1024,t=1,p=1$c2FsdA$a2V5", input: "x", wantErr: ErrInvalidHash},
+		{name: "malformed salt", hashed: "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5", input: "x", wantErr: ErrInvalidHash},
+		{name: "malformed key", hashed: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$!!!", input: "x", wantErr: ErrInvalidHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err = argon.Compare(tt.hashed, tt.input)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Compare() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Compare() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	var params = testParams()
+	var current, _ = NewWithParams(params).HashPassword("Tr4in-Ticket")
+
+	var iterations = params
+	iterations.Iterations = 2
+	var otherIterations, _ = NewWithParams(iterations).HashPassword("Tr4in-Ticket")
+
+	var shortSalt = params
+	shortSalt.SaltLength = 8
+	var shortSaltHash, _ = NewWithParams(shortSalt).HashPassword("Tr4in-Ticket")
+
+	var legacy, _ = bcrypt.GenerateFromPassword([]byte("Tr4in-Ticket"), bcrypt.MinCost)
+	var legacyCost, _ = bcrypt.GenerateFromPassword([]byte("Tr4in-Ticket"), bcrypt.MinCost+1)
+
+	var bcryptParams = params
+	bcryptParams.Algorithm = AlgorithmBcrypt
+
+	var tests = []struct {
+		name   string
+		params Params
+		hashed string
+		want   bool
+	}{
+		{name: "argon2id with current parameters", params: params, hashed: current, want: false},
+		{name: "argon2id with other iterations", params: params, hashed: otherIterations, want: true},
+		{name: "argon2id with other salt length", params: params, hashed: shortSaltHash, want: true},
+		{name: "argon2id after memory increase", params: Params{Algorithm: AlgorithmArgon2id, Memory: 2048, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, hashed: current, want: true},
+		{name: "legacy bcrypt under argon2id", params: params, hashed: string(legacy), want: true},
+		{name: "unparseable hash", params: params, hashed: "plaintext", want: true},
+		{name: "bcrypt with current cost", params: bcryptParams, hashed: string(legacy), want: false},
+		{name: "bcrypt with other cost", params: bcryptParams, hashed: string(legacyCost), want: true},
+		{name: "argon2id under bcrypt", params: bcryptParams, hashed: current, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewWithParams(tt.params).NeedsRehash(tt.hashed); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package jwt
+
+import "github.com/gin-gonic/gin"
+
+const principalKey = "jwt_principal"
+
+func SetPrincipal(g *gin.Context, principal ExtractToken) {
+	g.Set(principalKey, principal)
+}
+
+func GetPrincipal(g *gin.Context) (ExtractToken, bool) {
+	val, found := g.Get(principalKey)
+	if !found {
+		return ExtractToken{}, false
+	}
+
+	principal, ok := val.(ExtractToken)
+	return principal, ok
+}
@@ -7,19 +7,45 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
+	"strings"
 	"time"
 )
 
+const (
+	AccessTokenDuration  = configs.AccessTokenDuration
+	RefreshTokenDuration = time.Hour * 24 * 30
+	MFATokenDuration     = time.Minute * 5
+)
+
+const (
+	tokenTypeAccess     = "access"
+	tokenTypeMFAPending = "mfa_pending"
+)
+
+type Denylist interface {
+	IsRevoked(principal ExtractToken) bool
+}
+
+type SessionTracker interface {
+	Touch(principal ExtractToken, ip string)
+}
+
+type Revoker interface {
+	RevokeAccessTokens(userID uint) error
+}
+
 type JWTInterface interface {
-	GenerateJWT(id uint, username, email, phoneNumber, role string) map[string]any
-	RefreshJWT(accessToken string, refreshToken *jwt.Token) (map[string]any, error)
+	GenerateJWT(principal ExtractToken) map[string]any
 	ExtractToken(g *gin.Context) (ExtractToken, error)
-	ValidateRole(g *gin.Context) bool
-	GetCurrentToken(g *gin.Context) *jwt.Token
+	GenerateMFAToken(id uint) string
+	ValidateMFAToken(token string) (uint, error)
+	JWKS() map[string]any
+	RotateKeys() error
 }
 
 type JWT struct {
-	c *configs.ProgramConfig
+	c    *configs.ProgramConfig
+	keys *KeyRing
 }
 
 type ExtractToken struct {
@@ -27,19 +53,39 @@ type ExtractToken struct {
 	Username    string
 	Email       string
 	PhoneNumber string
-	Role        string
+	Roles       []string
+	Permissions []string
+	JTI         string
+	SessionID   string
+	IssuedAt    time.Time
+	ExpiredAt   time.Time
 }
 
-func NewJWT(c *configs.ProgramConfig) JWTInterface {
-	return &JWT{
-		c: c,
+func NewJWT(c *configs.ProgramConfig) (JWTInterface, error) {
+	keys, err := NewKeyRing(c.JWTKeyDir, c.JWTAlgorithm, c.JWTKeyRotation, c.JWTKeyGrace)
+	if err != nil {
+		logrus.Error("JWT : Init Signing Keys Error : ", err.Error())
+		return nil, err
 	}
+
+	return &JWT{
+		c:    c,
+		keys: keys,
+	}, nil
+}
+
+func (j *JWT) JWKS() map[string]any {
+	return j.keys.JWKS()
 }
 
-func (j *JWT) GenerateJWT(id uint, username, email, phoneNumber, role string) map[string]any {
+func (j *JWT) RotateKeys() error {
+	return j.keys.Rotate()
+}
+
+func (j *JWT) GenerateJWT(principal ExtractToken) map[string]any {
 	var result = map[string]any{}
-	var accessToken = j.generateToken(id, username, email, phoneNumber, role)
-	var refreshToken = j.generateRefreshToken()
+	var accessToken = j.generateToken(principal)
+	var refreshToken = GenerateRandomToken(32)
 	if accessToken == "" || refreshToken == "" {
 		return nil
 	}
@@ -50,146 +96,180 @@ func (j *JWT) GenerateJWT(id uint, username, email, phoneNumber, role string) ma
 	return result
 }
 
-func (j *JWT) generateToken(id uint, username, email, phoneNumber, role string) string {
+func (j *JWT) generateToken(principal ExtractToken) string {
 	var claims = jwt.MapClaims{}
-	claims["id"] = id
-	claims["username"] = username
-	claims["email"] = email
-	claims["phone_number"] = phoneNumber
-	claims["role"] = role
+	claims["id"] = principal.ID
+	claims["username"] = principal.Username
+	claims["email"] = principal.Email
+	claims["phone_number"] = principal.PhoneNumber
+	claims["roles"] = principal.Roles
+	claims["permissions"] = principal.Permissions
+	claims["typ"] = tokenTypeAccess
+	claims["sid"] = principal.SessionID
+	claims["jti"] = GenerateRandomToken(16)
 	claims["iat"] = time.Now().Unix()
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
+	claims["exp"] = time.Now().Add(AccessTokenDuration).Unix()
 
-	var sign = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	validToken, err := sign.SignedString([]byte(j.c.Secret))
-
-	if err != nil {
-		return ""
-	}
-
-	return validToken
+	return j.sign(claims)
 }
 
-func (j *JWT) generateRefreshToken() string {
+func (j *JWT) GenerateMFAToken(id uint) string {
 	var claims = jwt.MapClaims{}
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
-
-	var sign = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	refreshToken, err := sign.SignedString([]byte(j.c.RefSecret))
+	claims["id"] = id
+	claims["typ"] = tokenTypeMFAPending
+	claims["jti"] = GenerateRandomToken(16)
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(MFATokenDuration).Unix()
 
-	if err != nil {
-		return ""
-	}
-	return refreshToken
+	return j.sign(claims)
 }
 
-func (j *JWT) RefreshJWT(accessToken string, refreshToken *jwt.Token) (map[string]any, error) {
-	var result = map[string]any{}
-	expTime, err := refreshToken.Claims.GetExpirationTime()
+func (j *JWT) ValidateMFAToken(token string) (uint, error) {
+	parseToken, err := j.validateToken("Bearer " + token)
 	if err != nil {
-		logrus.Error("Get Token Expiration Error : ", err.Error())
-		return nil, errors.New("JWT : Token Expiration Error")
+		return 0, err
 	}
 
-	if refreshToken.Valid && expTime.Time.Compare(time.Now()) > 0 {
-		var newClaim = jwt.MapClaims{}
-		newToken, err := jwt.ParseWithClaims(accessToken, newClaim, func(token *jwt.Token) (interface{}, error) {
-			return []byte(j.c.Secret), nil
-		})
-
-		if err != nil {
-			logrus.Error("Parse Token Error : ", err.Error())
-			return nil, errors.New("JWT : Parse Token Error")
-		}
-
-		newClaim = newToken.Claims.(jwt.MapClaims)
-		newClaim["iat"] = time.Now().Unix()
-		newClaim["exp"] = time.Now().Add(time.Hour * 24).Unix()
+	mapClaims, ok := parseToken.Claims.(jwt.MapClaims)
+	if !ok || mapClaims["typ"] != tokenTypeMFAPending {
+		return 0, errors.New("JWT : Invalid MFA Token")
+	}
 
-		var newRefreshClaim = refreshToken.Claims.(jwt.MapClaims)
-		newRefreshClaim["exp"] = time.Now().Add(time.Hour * 24).Unix()
+	idFloat, ok := mapClaims["id"].(float64)
+	if !ok {
+		return 0, errors.New("JWT : ID not found or not a valid number")
+	}
 
-		var newRefreshToken = jwt.NewWithClaims(refreshToken.Method, newRefreshClaim)
-		newSignRefToken, err := newRefreshToken.SignedString(refreshToken.Signature)
+	return uint(idFloat), nil
+}
 
-		if err != nil {
-			logrus.Error("Sign Refresh Token Error : ", err.Error())
-			return nil, errors.New("JWT : Sign Refresh Token Error")
-		}
+func (j *JWT) sign(claims jwt.MapClaims) string {
+	key, method := j.keys.Active()
+	if key == nil {
+		logrus.Error("JWT : No Active Signing Key")
+		return ""
+	}
 
-		result["access_token"] = newToken.Raw
-		result["refresh_token"] = newSignRefToken
+	var sign = jwt.NewWithClaims(method, claims)
+	sign.Header["kid"] = key.ID
+	validToken, err := sign.SignedString(key.Private)
 
-		return result, nil
+	if err != nil {
+		logrus.Error("JWT : Sign Token Error : ", err.Error())
+		return ""
 	}
 
-	return nil, errors.New("JWT : Refresh Token Not Valid & Expired")
+	return validToken
 }
 
 func (j *JWT) validateToken(token string) (*jwt.Token, error) {
-	var authHeader = token[7:]
-	parseToken, err := jwt.Parse(authHeader, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("JWT : Unexpected Signing Method : ", t.Header["alg"])
+	if !strings.HasPrefix(token, "Bearer ") {
+		return nil, errors.New("JWT : Bearer Token Not Found")
+	}
+
+	var authHeader = strings.TrimSpace(token[len("Bearer "):])
+	var parser = jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+
+	parseToken, err := parser.Parse(authHeader, func(t *jwt.Token) (interface{}, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("JWT : Key ID Not Found : %v", t.Header["alg"])
 		}
-		return []byte(j.c.Secret), nil
+		return j.keys.PublicKey(kid, t.Method.Alg())
 	})
 
 	if err != nil {
 		logrus.Error("Parse Token Error : ", err.Error())
 		return nil, errors.New("JWT : Parse Token Error")
 	}
+
+	if !parseToken.Valid {
+		return nil, errors.New("JWT : Token Not Valid")
+	}
+
 	return parseToken, nil
 }
 
 func (j *JWT) ExtractToken(g *gin.Context) (ExtractToken, error) {
-	var result = new(ExtractToken)
 	authHeader := g.GetHeader("Authorization")
 
 	token, err := j.validateToken(authHeader)
-
 	if err != nil {
 		logrus.Error("Validate Token Error : ", err.Error())
+		return ExtractToken{}, err
 	}
 
-	mapClaims := token.Claims.(jwt.MapClaims)
-	idFloat, ok := mapClaims["id"].(float64)
-	email := mapClaims["email"].(string)
-	username := mapClaims["username"].(string)
-	phoneNumber := mapClaims["phone_number"].(string)
-	role := mapClaims["role"].(string)
+	return claimsToPrincipal(token)
+}
+
+func claimsToPrincipal(token *jwt.Token) (ExtractToken, error) {
+	var result = new(ExtractToken)
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ExtractToken{}, errors.New("JWT : Invalid Token Claims")
+	}
+
+	if mapClaims["typ"] != tokenTypeAccess {
+		return ExtractToken{}, errors.New("JWT : Invalid Token Type")
+	}
 
+	idFloat, ok := mapClaims["id"].(float64)
 	if !ok {
 		return ExtractToken{}, errors.New("JWT : ID not found or not a valid number")
 	}
 
+	jti, ok := mapClaims["jti"].(string)
+	if !ok || jti == "" {
+		return ExtractToken{}, errors.New("JWT : JTI not found")
+	}
+
+	issuedAt, err := mapClaims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return ExtractToken{}, errors.New("JWT : IAT not found")
+	}
+
+	expiredAt, err := mapClaims.GetExpirationTime()
+	if err != nil || expiredAt == nil {
+		return ExtractToken{}, errors.New("JWT : EXP not found")
+	}
+
+	sessionID, _ := mapClaims["sid"].(string)
+	username, _ := mapClaims["username"].(string)
+	email, _ := mapClaims["email"].(string)
+	phoneNumber, _ := mapClaims["phone_number"].(string)
+
 	result.ID = uint(idFloat)
 	result.Email = email
 	result.Username = username
 	result.PhoneNumber = phoneNumber
-	result.Role = role
+	result.Roles = claimToStrings(mapClaims["roles"])
+	result.Permissions = claimToStrings(mapClaims["permissions"])
+	result.JTI = jti
+	result.SessionID = sessionID
+	result.IssuedAt = issuedAt.Time
+	result.ExpiredAt = expiredAt.Time
 
 	return *result, nil
 }
 
-func (j *JWT) GetCurrentToken(g *gin.Context) *jwt.Token {
-	authHeader := g.GetHeader("Authorization")
-
-	token, err := j.validateToken(authHeader)
+func claimToStrings(claim any) []string {
+	var result []string
 
-	if err != nil {
-		logrus.Error("Validate Token Error : ", err.Error())
+	values, ok := claim.([]interface{})
+	if !ok {
+		return result
 	}
 
-	return token
-}
-
-func (j *JWT) ValidateRole(g *gin.Context) bool {
-	ext, err := j.ExtractToken(g)
-	if err != nil {
-		logrus.Error("Validate Role Error : ", err.Error())
-		return false
+	for _, val := range values {
+		if str, ok := val.(string); ok {
+			result = append(result, str)
+		}
 	}
 
-	return ext.Role == "Admin"
+	return result
 }
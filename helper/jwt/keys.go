@@ -0,0 +1,304 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const keyReloadInterval = time.Second * 10
+
+type signingKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+type KeyRing struct {
+	mu         sync.RWMutex
+	dir        string
+	algorithm  string
+	rotation   time.Duration
+	grace      time.Duration
+	keys       []*signingKey
+	reloadedAt time.Time
+}
+
+func NewKeyRing(dir, algorithm string, rotation, grace time.Duration) (*KeyRing, error) {
+	var ring = &KeyRing{
+		dir:       dir,
+		algorithm: algorithm,
+		rotation:  rotation,
+		grace:     grace,
+	}
+
+	if _, err := signingMethod(algorithm); err != nil {
+		return ring, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return ring, err
+	}
+
+	return ring, ring.Rotate()
+}
+
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		return jwt.SigningMethodRS256, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("JWT : Unsupported Signing Algorithm : %s", algorithm)
+}
+
+func (k *KeyRing) Rotate() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.load(); err != nil {
+		return err
+	}
+
+	var latest = k.latest()
+	if latest == nil || latest.Algorithm != k.algorithm || time.Since(latest.CreatedAt) >= k.rotation {
+		newKey, err := k.generate()
+		if err != nil {
+			return err
+		}
+		k.keys = append(k.keys, newKey)
+		logrus.Info("JWT : Signing Key Rotated, Active Key : ", newKey.ID)
+	}
+
+	k.prune()
+	return nil
+}
+
+func (k *KeyRing) load() error {
+	files, err := filepath.Glob(filepath.Join(k.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	var keys []*signingKey
+	for _, file := range files {
+		key, err := readKey(file)
+		if err != nil {
+			logrus.Error("JWT : Read Signing Key Error : ", file, " : ", err.Error())
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	k.keys = keys
+	k.reloadedAt = time.Now()
+	return nil
+}
+
+func readKey(file string) (*signingKey, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("invalid pem block")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, block.Headers["Created"])
+	if err != nil {
+		return nil, err
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key")
+	}
+
+	return &signingKey{
+		ID:        strings.TrimSuffix(filepath.Base(file), ".pem"),
+		Algorithm: block.Headers["Algorithm"],
+		Private:   signer,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+func (k *KeyRing) generate() (*signingKey, error) {
+	var private crypto.Signer
+	var err error
+
+	switch k.algorithm {
+	case jwt.SigningMethodEdDSA.Alg():
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	var key = &signingKey{
+		ID:        fmt.Sprintf("%d-%s", time.Now().Unix(), GenerateRandomToken(6)),
+		Algorithm: k.algorithm,
+		Private:   private,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	var block = &pem.Block{
+		Type: "PRIVATE KEY",
+		Headers: map[string]string{
+			"Algorithm": key.Algorithm,
+			"Created":   key.CreatedAt.Format(time.RFC3339),
+		},
+		Bytes: der,
+	}
+
+	if err := os.WriteFile(filepath.Join(k.dir, key.ID+".pem"), pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (k *KeyRing) prune() {
+	var keys []*signingKey
+
+	for i, key := range k.keys {
+		if i < len(k.keys)-1 && time.Since(k.keys[i+1].CreatedAt) > k.grace {
+			if err := os.Remove(filepath.Join(k.dir, key.ID+".pem")); err != nil && !os.IsNotExist(err) {
+				logrus.Error("JWT : Remove Signing Key Error : ", err.Error())
+			}
+			logrus.Info("JWT : Signing Key Retired : ", key.ID)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	k.keys = keys
+}
+
+func (k *KeyRing) latest() *signingKey {
+	if len(k.keys) == 0 {
+		return nil
+	}
+	return k.keys[len(k.keys)-1]
+}
+
+func (k *KeyRing) Active() (*signingKey, jwt.SigningMethod) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	var key = k.latest()
+	if key == nil {
+		return nil, nil
+	}
+
+	method, err := signingMethod(key.Algorithm)
+	if err != nil {
+		return nil, nil
+	}
+
+	return key, method
+}
+
+func (k *KeyRing) PublicKey(kid, algorithm string) (crypto.PublicKey, error) {
+	if key := k.find(kid); key != nil {
+		return verifyingKey(key, algorithm)
+	}
+
+	k.mu.Lock()
+	if time.Since(k.reloadedAt) > keyReloadInterval {
+		if err := k.load(); err != nil {
+			logrus.Error("JWT : Reload Signing Keys Error : ", err.Error())
+		}
+	}
+	k.mu.Unlock()
+
+	if key := k.find(kid); key != nil {
+		return verifyingKey(key, algorithm)
+	}
+
+	return nil, errors.New("JWT : Unknown Signing Key")
+}
+
+func verifyingKey(key *signingKey, algorithm string) (crypto.PublicKey, error) {
+	if key.Algorithm != algorithm {
+		return nil, fmt.Errorf("JWT : Unexpected Signing Method : %s", algorithm)
+	}
+	return key.Private.Public(), nil
+}
+
+func (k *KeyRing) find(kid string) *signingKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.ID == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+func (k *KeyRing) JWKS() map[string]any {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	var keys = []map[string]any{}
+	for _, key := range k.keys {
+		var jwk = map[string]any{
+			"kid": key.ID,
+			"use": "sig",
+			"alg": key.Algorithm,
+		}
+
+		switch public := key.Private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		keys = append(keys, jwk)
+	}
+
+	return map[string]any{"keys": keys}
+}
@@ -0,0 +1,162 @@
+package jwt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestRing(t *testing.T, algorithm string, rotation, grace time.Duration) *KeyRing {
+	t.Helper()
+
+	ring, err := NewKeyRing(t.TempDir(), algorithm, rotation, grace)
+	if err != nil {
+		t.Fatalf("NewKeyRing() error = %v", err)
+	}
+
+	return ring
+}
+
+func keyFiles(t *testing.T, dir string) int {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+
+	return len(files)
+}
+
+func TestNewKeyRingUnsupportedAlgorithm(t *testing.T) {
+	if _, err := NewKeyRing(t.TempDir(), "HS256", time.Hour, time.Hour); err == nil {
+		t.Fatal("NewKeyRing() error = nil, want unsupported algorithm")
+	}
+}
+
+func TestKeyRingRotate(t *testing.T) {
+	var tests = []struct {
+		name      string
+		algorithm string
+		rotation  time.Duration
+		switchTo  string
+		wantKeys  int
+		wantNewID bool
+	}{
+		{name: "active key is kept before rotation is due", algorithm: "EdDSA", rotation: time.Hour, wantKeys: 1},
+		{name: "key is rotated once rotation is due", algorithm: "EdDSA", rotation: 0, wantKeys: 2, wantNewID: true},
+		{name: "algorithm change forces a new key", algorithm: "EdDSA", rotation: time.Hour, switchTo: "RS256", wantKeys: 2, wantNewID: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ring = newTestRing(t, tt.algorithm, tt.rotation, time.Hour)
+			first, _ := ring.Active()
+
+			if tt.switchTo != "" {
+				ring.algorithm = tt.switchTo
+			}
+
+			if err := ring.Rotate(); err != nil {
+				t.Fatalf("Rotate() error = %v", err)
+			}
+
+			active, method := ring.Active()
+			if active == nil || method == nil {
+				t.Fatal("Active() returned no key")
+			}
+			if got := active.ID != first.ID; got != tt.wantNewID {
+				t.Errorf("new active key = %v, want %v", got, tt.wantNewID)
+			}
+			if tt.switchTo != "" && method.Alg() != tt.switchTo {
+				t.Errorf("active method = %s, want %s", method.Alg(), tt.switchTo)
+			}
+			if got := len(ring.keys); got != tt.wantKeys {
+				t.Errorf("keys = %d, want %d", got, tt.wantKeys)
+			}
+			if got := keyFiles(t, ring.dir); got != tt.wantKeys {
+				t.Errorf("key files = %d, want %d", got, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestKeyRingPrune(t *testing.T) {
+	var grace = time.Hour * 24
+
+	var tests = []struct {
+		name string
+		ages []time.Duration
+		want []bool
+	}{
+		{name: "single key is never retired", ages: []time.Duration{time.Hour * 1000}, want: []bool{true}},
+		{name: "previous key is kept within grace", ages: []time.Duration{time.Hour * 48, time.Hour}, want: []bool{true, true}},
+		{name: "previous key is retired after grace", ages: []time.Duration{time.Hour * 72, time.Hour * 25}, want: []bool{false, true}},
+		{name: "grace runs from the successor's creation", ages: []time.Duration{time.Hour * 100, time.Hour * 30, time.Hour * 2}, want: []bool{false, true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ring = newTestRing(t, "EdDSA", time.Hour*1000, grace)
+			os.Remove(filepath.Join(ring.dir, ring.keys[0].ID+".pem"))
+			ring.keys = nil
+
+			for _, age := range tt.ages {
+				key, err := ring.generate()
+				if err != nil {
+					t.Fatalf("generate() error = %v", err)
+				}
+				key.CreatedAt = time.Now().Add(-age)
+				ring.keys = append(ring.keys, key)
+			}
+			var generated = append([]*signingKey(nil), ring.keys...)
+
+			ring.prune()
+
+			for i, key := range generated {
+				var kept = ring.find(key.ID) != nil
+				if kept != tt.want[i] {
+					t.Errorf("key %d kept = %v, want %v", i, kept, tt.want[i])
+				}
+
+				_, err := os.Stat(filepath.Join(ring.dir, key.ID+".pem"))
+				if exists := err == nil; exists != tt.want[i] {
+					t.Errorf("key %d file exists = %v, want %v", i, exists, tt.want[i])
+				}
+			}
+
+			if active, _ := ring.Active(); active.ID != generated[len(generated)-1].ID {
+				t.Errorf("active key = %s, want newest %s", active.ID, generated[len(generated)-1].ID)
+			}
+		})
+	}
+}
+
+func TestKeyRingPublicKey(t *testing.T) {
+	var ring = newTestRing(t, "EdDSA", time.Hour, time.Hour)
+	active, _ := ring.Active()
+
+	var tests = []struct {
+		name      string
+		kid       string
+		algorithm string
+		wantErr   bool
+	}{
+		{name: "known key and algorithm", kid: active.ID, algorithm: "EdDSA"},
+		{name: "algorithm mismatch is rejected", kid: active.ID, algorithm: "RS256", wantErr: true},
+		{name: "unknown key is rejected", kid: "missing", algorithm: "EdDSA", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ring.PublicKey(tt.kid, tt.algorithm)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PublicKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && key == nil {
+				t.Error("PublicKey() returned nil key")
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package jwt
+
+import (
+	"e-ticketing-gin/helper"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+func (e ExtractToken) HasRole(role string) bool {
+	for _, val := range e.Roles {
+		if val == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ExtractToken) HasPermission(permission string) bool {
+	for _, val := range e.Permissions {
+		if val == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
+			return
+		}
+
+		for _, permission := range permissions {
+			if !principal.HasPermission(permission) {
+				logrus.Error("Middleware : Forbidden : missing permission ", permission, " for user ", principal.Username)
+				c.AbortWithStatusJSON(http.StatusForbidden, helper.FormatResponse("Restricted Access", nil))
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,24 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/sirupsen/logrus"
+)
+
+func GenerateRandomToken(length int) string {
+	var buff = make([]byte, length)
+	if _, err := rand.Read(buff); err != nil {
+		logrus.Error("Generate Random Token Error : ", err.Error())
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buff)
+}
+
+func HashToken(token string) string {
+	var sum = sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
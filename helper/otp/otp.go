@@ -0,0 +1,64 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"e-ticketing-gin/configs"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"time"
+)
+
+type OTPInterface interface {
+	GenerateCode() (string, error)
+	HashCode(username, code string) string
+	CompareCode(hash, username, code string) bool
+	MaxAttempts() int
+	Expiry() time.Duration
+}
+
+type OTP struct {
+	c *configs.ProgramConfig
+}
+
+func NewOTP(c *configs.ProgramConfig) OTPInterface {
+	return &OTP{
+		c: c,
+	}
+}
+
+func (o *OTP) GenerateCode() (string, error) {
+	var max = big.NewInt(10)
+	var code = make([]byte, o.c.OTPLength)
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+
+	return string(code), nil
+}
+
+func (o *OTP) HashCode(username, code string) string {
+	var mac = hmac.New(sha256.New, []byte(o.c.Secret))
+	mac.Write([]byte(strings.ToLower(username) + ":" + strings.TrimSpace(code)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (o *OTP) CompareCode(hash, username, code string) bool {
+	return hmac.Equal([]byte(hash), []byte(o.HashCode(username, code)))
+}
+
+func (o *OTP) MaxAttempts() int {
+	return o.c.OTPMaxAttempts
+}
+
+func (o *OTP) Expiry() time.Duration {
+	return o.c.OTPExpiry
+}
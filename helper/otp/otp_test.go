@@ -0,0 +1,92 @@
+package otp
+
+import (
+	"e-ticketing-gin/configs"
+	"testing"
+)
+
+func newTestOTP(secret string) *OTP {
+	return &OTP{c: &configs.ProgramConfig{Secret: secret, OTPLength: 6}}
+}
+
+func TestGenerateCode(t *testing.T) {
+	var otp = newTestOTP("test-secret")
+
+	for i := 0; i < 50; i++ {
+		code, err := otp.GenerateCode()
+		if err != nil {
+			t.Fatalf("GenerateCode() error = %v", err)
+		}
+		if len(code) != 6 {
+			t.Fatalf("GenerateCode() = %q, want 6 digits", code)
+		}
+		for _, char := range code {
+			if char < '0' || char > '9' {
+				t.Fatalf("GenerateCode() = %q, want digits only", code)
+			}
+		}
+	}
+}
+
+func TestHashCode(t *testing.T) {
+	var otp = newTestOTP("test-secret")
+	var hash = otp.HashCode("alice", "123456")
+
+	if len(hash) != 64 {
+		t.Fatalf("HashCode() length = %d, want 64", len(hash))
+	}
+	if hash == "123456" {
+		t.Fatal("HashCode() returned the plain code")
+	}
+
+	var tests = []struct {
+		name     string
+		otp      *OTP
+		username string
+		code     string
+		wantSame bool
+	}{
+		{name: "same input", otp: otp, username: "alice", code: "123456", wantSame: true},
+		{name: "username is case-insensitive", otp: otp, username: "ALICE", code: "123456", wantSame: true},
+		{name: "code is trimmed", otp: otp, username: "alice", code: " 123456 ", wantSame: true},
+		{name: "different code", otp: otp, username: "alice", code: "123457", wantSame: false},
+		{name: "different username", otp: otp, username: "bob", code: "123456", wantSame: false},
+		{name: "different secret", otp: newTestOTP("other-secret"), username: "alice", code: "123456", wantSame: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.otp.HashCode(tt.username, tt.code) == hash; got != tt.wantSame {
+				t.Errorf("HashCode() equal = %v, want %v", got, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestCompareCode(t *testing.T) {
+	var otp = newTestOTP("test-secret")
+	var hash = otp.HashCode("alice", "123456")
+
+	var tests = []struct {
+		name     string
+		hash     string
+		username string
+		code     string
+		want     bool
+	}{
+		{name: "matching code", hash: hash, username: "alice", code: "123456", want: true},
+		{name: "matching code with other casing", hash: hash, username: "Alice", code: "123456", want: true},
+		{name: "wrong code", hash: hash, username: "alice", code: "654321", want: false},
+		{name: "code issued to another user", hash: hash, username: "bob", code: "123456", want: false},
+		{name: "plain code as hash", hash: "123456", username: "alice", code: "123456", want: false},
+		{name: "empty hash", hash: "", username: "alice", code: "123456", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := otp.CompareCode(tt.hash, tt.username, tt.code); got != tt.want {
+				t.Errorf("CompareCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package helper
+
+type Pagination struct {
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	TotalData  int64  `json:"total_data"`
+	TotalPage  int    `json:"total_page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func NewPagination(page, limit int, total int64, nextCursor string) Pagination {
+	var result = Pagination{
+		Limit:      limit,
+		TotalData:  total,
+		NextCursor: nextCursor,
+	}
+
+	if page > 0 {
+		result.Page = page
+		result.TotalPage = int((total + int64(limit) - 1) / int64(limit))
+	}
+
+	return result
+}
@@ -0,0 +1,177 @@
+package password
+
+import (
+	"bufio"
+	"e-ticketing-gin/configs"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassLetter = "letter"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleIdentity  = "identity"
+	RuleBreached  = "breached"
+
+	minIdentityLength = 3
+)
+
+var Classes = []string{ClassLower, ClassUpper, ClassLetter, ClassDigit, ClassSymbol}
+
+var classMessages = map[string]string{
+	ClassLower:  "Password must contain a lowercase letter",
+	ClassUpper:  "Password must contain an uppercase letter",
+	ClassLetter: "Password must contain a letter",
+	ClassDigit:  "Password must contain a number",
+	ClassSymbol: "Password must contain a symbol",
+}
+
+//go:embed breached.txt
+var breachedList string
+
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type PolicyInterface interface {
+	Validate(password string, identities ...string) []Violation
+}
+
+type Policy struct {
+	minLength int
+	maxLength int
+	classes   []string
+	breached  map[string]struct{}
+}
+
+func NewPolicy(c *configs.ProgramConfig) PolicyInterface {
+	var policy = &Policy{
+		minLength: c.PasswordMinLength,
+		maxLength: c.PasswordMaxLength,
+		classes:   c.PasswordClasses,
+	}
+
+	if c.PasswordBreachedCheck {
+		policy.breached = loadBreached(breachedList)
+	}
+
+	return policy
+}
+
+func loadBreached(source string) map[string]struct{} {
+	var result = map[string]struct{}{}
+
+	var scanner = bufio.NewScanner(strings.NewReader(source))
+	for scanner.Scan() {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result[strings.ToLower(line)] = struct{}{}
+	}
+
+	return result
+}
+
+func (p *Policy) Validate(password string, identities ...string) []Violation {
+	var result = []Violation{}
+	var length = utf8.RuneCountInString(password)
+
+	if length < p.minLength {
+		result = append(result, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("Password must be at least %d characters", p.minLength),
+		})
+	}
+
+	if p.maxLength > 0 && length > p.maxLength {
+		result = append(result, Violation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("Password must be at most %d characters", p.maxLength),
+		})
+	}
+
+	var present = characterClasses(password)
+	for _, class := range p.classes {
+		if !present[class] {
+			result = append(result, Violation{
+				Rule:    class,
+				Message: classMessages[class],
+			})
+		}
+	}
+
+	var lower = strings.ToLower(password)
+	for _, identity := range identityParts(identities) {
+		if strings.Contains(lower, identity) {
+			result = append(result, Violation{
+				Rule:    RuleIdentity,
+				Message: "Password must not contain your username or email",
+			})
+			break
+		}
+	}
+
+	if _, found := p.breached[lower]; found {
+		result = append(result, Violation{
+			Rule:    RuleBreached,
+			Message: "Password is too common and has appeared in data breaches",
+		})
+	}
+
+	return result
+}
+
+func characterClasses(password string) map[string]bool {
+	var result = map[string]bool{}
+
+	for _, char := range password {
+		switch {
+		case unicode.IsLower(char):
+			result[ClassLower] = true
+			result[ClassLetter] = true
+		case unicode.IsUpper(char):
+			result[ClassUpper] = true
+			result[ClassLetter] = true
+		case unicode.IsLetter(char):
+			result[ClassLetter] = true
+		case unicode.IsDigit(char):
+			result[ClassDigit] = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			result[ClassSymbol] = true
+		}
+	}
+
+	return result
+}
+
+func identityParts(identities []string) []string {
+	var result []string
+
+	for _, identity := range identities {
+		identity = strings.ToLower(strings.TrimSpace(identity))
+
+		var candidates = []string{identity}
+		if i := strings.LastIndex(identity, "@"); i > 0 {
+			candidates = append(candidates, identity[:i])
+		}
+
+		for _, val := range candidates {
+			if utf8.RuneCountInString(val) >= minIdentityLength {
+				result = append(result, val)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,106 @@
+package password
+
+import (
+	"e-ticketing-gin/configs"
+	"reflect"
+	"testing"
+)
+
+func rules(violations []Violation) []string {
+	var result = []string{}
+	for _, val := range violations {
+		result = append(result, val.Rule)
+	}
+	return result
+}
+
+func TestValidate(t *testing.T) {
+	var policy = NewPolicy(&configs.ProgramConfig{
+		PasswordMinLength:     8,
+		PasswordMaxLength:     16,
+		PasswordClasses:       []string{ClassLetter, ClassDigit, ClassSymbol},
+		PasswordBreachedCheck: true,
+	})
+
+	var tests = []struct {
+		name       string
+		password   string
+		identities []string
+		want       []string
+	}{
+		{name: "valid password", password: "Tr4in-Ticket", want: []string{}},
+		{name: "too short", password: "a1!", want: []string{RuleMinLength}},
+		{name: "too long", password: "abcdefgh1234567!x", want: []string{RuleMaxLength}},
+		{name: "length counts runes", password: "ééééééé1!", want: []string{}},
+		{name: "missing digit", password: "Train-Ticket", want: []string{ClassDigit}},
+		{name: "missing symbol and letter", password: "1234567890", want: []string{ClassLetter, ClassSymbol, RuleBreached}},
+		{name: "contains username", password: "xAlice-2024!", identities: []string{"alice", "alice@example.com"}, want: []string{RuleIdentity}},
+		{name: "contains email local part", password: "bobby-2024!x", identities: []string{"robert", "bobby@example.com"}, want: []string{RuleIdentity}},
+		{name: "short identity is ignored", password: "al-Ticket-9", identities: []string{"al"}, want: []string{}},
+		{name: "breached password", password: "password", want: []string{ClassDigit, ClassSymbol, RuleBreached}},
+		{name: "breached check is case-insensitive", password: "PASSWORD", want: []string{ClassDigit, ClassSymbol, RuleBreached}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(policy.Validate(tt.password, tt.identities...)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateClasses(t *testing.T) {
+	var policy = NewPolicy(&configs.ProgramConfig{
+		PasswordClasses: []string{ClassLower, ClassUpper},
+	})
+
+	var tests = []struct {
+		password string
+		want     []string
+	}{
+		{password: "Ticket", want: []string{}},
+		{password: "ticket", want: []string{ClassUpper}},
+		{password: "TICKET", want: []string{ClassLower}},
+		{password: "123456", want: []string{ClassLower, ClassUpper}},
+	}
+
+	for _, tt := range tests {
+		if got := rules(policy.Validate(tt.password)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Validate(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestBreachedCheckDisabled(t *testing.T) {
+	var policy = NewPolicy(&configs.ProgramConfig{PasswordMinLength: 1})
+
+	if got := rules(policy.Validate("password")); len(got) != 0 {
+		t.Errorf("Validate() = %v, want no violations", got)
+	}
+}
+
+func TestLoadBreached(t *testing.T) {
+	var list = loadBreached("# comment\n\n  Password \nqwerty\n#hunter2\n")
+
+	var tests = []struct {
+		password string
+		want     bool
+	}{
+		{password: "password", want: true},
+		{password: "qwerty", want: true},
+		{password: "hunter2", want: false},
+		{password: "# comment", want: false},
+		{password: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if _, found := list[tt.password]; found != tt.want {
+			t.Errorf("breached[%q] = %v, want %v", tt.password, found, tt.want)
+		}
+	}
+
+	if len(loadBreached(breachedList)) < 100 {
+		t.Error("embedded breached list has fewer than 100 entries")
+	}
+}
@@ -0,0 +1,55 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderName = "X-Request-ID"
+	contextKey = "request_id"
+	maxLength  = 64
+)
+
+func New() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id = c.GetHeader(HeaderName)
+		if !valid(id) {
+			id = generate()
+		}
+
+		c.Set(contextKey, id)
+		c.Header(HeaderName, id)
+		c.Next()
+	}
+}
+
+func Get(c *gin.Context) string {
+	return c.GetString(contextKey)
+}
+
+func generate() string {
+	var buf = make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, char := range id {
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z', char >= '0' && char <= '9':
+		case char == '-', char == '_', char == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -9,6 +9,14 @@ func FormatResponse(message string, data any) map[string]any {
 	return response
 }
 
+func FormatResponsePagination(message string, data any, meta Pagination) map[string]any {
+	var response = map[string]any{}
+	response["message"] = message
+	response["data"] = data
+	response["meta"] = meta
+	return response
+}
+
 func FormatResponseValidation(message string, msgErr any) map[string]any {
 	var response = map[string]any{}
 	response["message"] = message
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type LocalDriver struct {
+	root      string
+	publicURL string
+}
+
+func NewLocalDriver(root, publicURL string) (*LocalDriver, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalDriver{
+		root:      root,
+		publicURL: strings.TrimRight(publicURL, "/"),
+	}, nil
+}
+
+func (d *LocalDriver) Put(key, contentType string, body []byte) (string, error) {
+	var name = filepath.Join(d.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return "", err
+	}
+
+	return d.publicURL + "/" + key, nil
+}
+
+func (d *LocalDriver) Delete(key string) error {
+	var err = os.Remove(filepath.Join(d.root, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (d *LocalDriver) Mount(router *gin.Engine) {
+	if !strings.HasPrefix(d.publicURL, "/") {
+		return
+	}
+
+	router.StaticFS(d.publicURL, gin.Dir(d.root, false))
+}
+
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return errors.New("STORAGE : Invalid Object Key")
+	}
+
+	return nil
+}
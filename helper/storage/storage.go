@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"e-ticketing-gin/configs"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type StorageInterface interface {
+	Put(key, contentType string, body []byte) (string, error)
+	Delete(key string) error
+	Mount(router *gin.Engine)
+}
+
+type Driver interface {
+	Put(key, contentType string, body []byte) (string, error)
+	Delete(key string) error
+}
+
+type Storage struct {
+	driver Driver
+}
+
+func NewDriver(c *configs.ProgramConfig) (Driver, error) {
+	switch c.StorageDriver {
+	case "local":
+		return NewLocalDriver(c.StorageLocalDir, c.StoragePublicURL)
+	case "cloudinary":
+		return NewCloudinaryDriver(c.CloudURL, c.StorageEndpoint)
+	}
+	return nil, fmt.Errorf("STORAGE : Unsupported Storage Driver : %s", c.StorageDriver)
+}
+
+func NewStorage(c *configs.ProgramConfig) (StorageInterface, error) {
+	driver, err := NewDriver(c)
+	if err != nil {
+		logrus.Error("STORAGE : Init Storage Driver Error : ", err.Error())
+		return nil, err
+	}
+
+	return NewStorageWithDriver(driver), nil
+}
+
+func NewStorageWithDriver(d Driver) StorageInterface {
+	return &Storage{
+		driver: d,
+	}
+}
+
+func (s *Storage) Put(key, contentType string, body []byte) (string, error) {
+	if s.driver == nil {
+		return "", errors.New("STORAGE : Storage Driver Not Configured")
+	}
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+
+	return s.driver.Put(key, contentType, body)
+}
+
+func (s *Storage) Delete(key string) error {
+	if s.driver == nil {
+		return errors.New("STORAGE : Storage Driver Not Configured")
+	}
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	return s.driver.Delete(key)
+}
+
+func (s *Storage) Mount(router *gin.Engine) {
+	if local, ok := s.driver.(*LocalDriver); ok {
+		local.Mount(router)
+	}
+}
@@ -0,0 +1,175 @@
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"e-ticketing-gin/configs"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/skip2/go-qrcode"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	period = 30
+	digits = 6
+	skew   = 1
+)
+
+type TOTPInterface interface {
+	GenerateSecret() (string, error)
+	URI(secret, account string) string
+	QRCode(uri string) ([]byte, error)
+	Validate(secret, code string) (int64, bool)
+	Encrypt(secret string) (string, error)
+	Decrypt(sealed string) (string, error)
+	GenerateRecoveryCodes(count int) ([]string, error)
+	HashRecoveryCode(code string) string
+}
+
+type TOTP struct {
+	c *configs.ProgramConfig
+}
+
+func NewTOTP(c *configs.ProgramConfig) TOTPInterface {
+	return &TOTP{
+		c: c,
+	}
+}
+
+func (t *TOTP) GenerateSecret() (string, error) {
+	var secret = make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret), nil
+}
+
+func (t *TOTP) URI(secret, account string) string {
+	var query = url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", t.c.MFAIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(period))
+
+	var label = url.PathEscape(t.c.MFAIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func (t *TOTP) QRCode(uri string) ([]byte, error) {
+	return qrcode.Encode(uri, qrcode.Medium, 256)
+}
+
+func (t *TOTP) Validate(secret, code string) (int64, bool) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != digits {
+		return 0, false
+	}
+
+	var current = time.Now().Unix() / period
+	for step := current - skew; step <= current+skew; step++ {
+		if subtle.ConstantTimeCompare([]byte(generateCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+func generateCode(key []byte, step int64) string {
+	var msg = make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	var mac = hmac.New(sha1.New, key)
+	mac.Write(msg)
+	var sum = mac.Sum(nil)
+
+	var offset = sum[len(sum)-1] & 0x0f
+	var value = binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
+
+func (t *TOTP) Encrypt(secret string) (string, error) {
+	gcm, err := t.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	var nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	var sealed = gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (t *TOTP) Decrypt(sealed string) (string, error) {
+	gcm, err := t.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("TOTP : Invalid Sealed Secret")
+	}
+
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+func (t *TOTP) cipher() (cipher.AEAD, error) {
+	var key = sha256.Sum256([]byte(t.c.Secret))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (t *TOTP) GenerateRecoveryCodes(count int) ([]string, error) {
+	var codes []string
+
+	for i := 0; i < count; i++ {
+		var buff = make([]byte, 5)
+		if _, err := rand.Read(buff); err != nil {
+			return nil, err
+		}
+
+		var code = strings.ToLower(base32.StdEncoding.EncodeToString(buff))
+		codes = append(codes, code[:4]+"-"+code[4:])
+	}
+
+	return codes, nil
+}
+
+func (t *TOTP) HashRecoveryCode(code string) string {
+	var normalized = strings.ToLower(strings.TrimSpace(code))
+	var sum = sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,124 @@
+package totp
+
+import (
+	"e-ticketing-gin/configs"
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestTOTP() *TOTP {
+	return &TOTP{c: &configs.ProgramConfig{Secret: "test-secret", MFAIssuer: "E-Ticketing"}}
+}
+
+func TestGenerateCodeRFC6238(t *testing.T) {
+	var key = []byte("12345678901234567890")
+
+	var tests = []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		if got := generateCode(key, tt.unix/period); got != tt.want {
+			t.Errorf("generateCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var totp = newTestTOTP()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	key, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+
+	var tests = []struct {
+		name   string
+		secret string
+		offset int64
+		code   func(step int64) string
+		wantOK bool
+	}{
+		{name: "current step", secret: secret, offset: 0, wantOK: true},
+		{name: "previous step within skew", secret: secret, offset: -1, wantOK: true},
+		{name: "next step within skew", secret: secret, offset: 1, wantOK: true},
+		{name: "step outside skew", secret: secret, offset: -2, wantOK: false},
+		{name: "future step outside skew", secret: secret, offset: 2, wantOK: false},
+		{name: "lowercase secret", secret: strings.ToLower(secret), offset: 0, wantOK: true},
+		{name: "wrong length", secret: secret, code: func(step int64) string { return generateCode(key, step)[:5] }, wantOK: false},
+		{name: "invalid secret", secret: "not base32!", offset: 0, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for {
+				var current = time.Now().Unix() / period
+				var code = generateCode(key, current+tt.offset)
+				if tt.code != nil {
+					code = tt.code(current)
+				}
+
+				step, ok := totp.Validate(tt.secret, code)
+				if time.Now().Unix()/period != current {
+					continue
+				}
+
+				if ok != tt.wantOK {
+					t.Fatalf("Validate() ok = %v, want %v", ok, tt.wantOK)
+				}
+				if ok && step != current+tt.offset {
+					t.Errorf("Validate() step = %d, want %d", step, current+tt.offset)
+				}
+				return
+			}
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	var totp = newTestTOTP()
+
+	sealed, err := totp.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	plain, err := totp.Decrypt(sealed)
+	if err != nil || plain != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("Decrypt() = %q, %v", plain, err)
+	}
+
+	var other = &TOTP{c: &configs.ProgramConfig{Secret: "other-secret"}}
+	if _, err := other.Decrypt(sealed); err == nil {
+		t.Error("Decrypt() with another key error = nil, want failure")
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	var totp = newTestTOTP()
+
+	codes, err := totp.GenerateRecoveryCodes(10)
+	if err != nil || len(codes) != 10 {
+		t.Fatalf("GenerateRecoveryCodes() = %d codes, %v", len(codes), err)
+	}
+
+	for _, code := range codes {
+		if totp.HashRecoveryCode(code) != totp.HashRecoveryCode(" "+strings.ToUpper(code)+" ") {
+			t.Errorf("HashRecoveryCode(%s) is not normalized", code)
+		}
+	}
+
+	if totp.HashRecoveryCode(codes[0]) == totp.HashRecoveryCode(codes[1]) {
+		t.Error("HashRecoveryCode() collided for different codes")
+	}
+}
@@ -2,7 +2,6 @@ package helper
 
 import (
 	"github.com/go-playground/validator/v10"
-	"unicode"
 )
 
 var validate *validator.Validate
@@ -20,23 +19,3 @@ func ValidateJSON(data interface{}) (bool, map[string]string) {
 	}
 	return true, nil
 }
-
-func ValidatePassword(password string) bool {
-	hasLetter := false
-	hasDigit := false
-	hasSymbol := false
-
-	for _, char := range password {
-		if unicode.IsLetter(char) {
-			hasLetter = true
-		} else if unicode.IsDigit(char) {
-			hasDigit = true
-		} else if unicode.IsPunct(char) || unicode.IsSymbol(char) {
-			hasSymbol = true
-		}
-	}
-
-	result := hasLetter && hasDigit && hasSymbol
-
-	return result
-}
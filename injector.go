@@ -5,6 +5,18 @@ package main
 
 import (
 	"e-ticketing-gin/configs"
+	"e-ticketing-gin/features/audit"
+	auditData "e-ticketing-gin/features/audit/data"
+	auditHandler "e-ticketing-gin/features/audit/handler"
+	auditService "e-ticketing-gin/features/audit/service"
+	"e-ticketing-gin/features/outbox"
+	outboxData "e-ticketing-gin/features/outbox/data"
+	outboxHandler "e-ticketing-gin/features/outbox/handler"
+	outboxService "e-ticketing-gin/features/outbox/service"
+	"e-ticketing-gin/features/roles"
+	roleData "e-ticketing-gin/features/roles/data"
+	roleHandler "e-ticketing-gin/features/roles/handler"
+	roleService "e-ticketing-gin/features/roles/service"
 	"e-ticketing-gin/features/users"
 	userData "e-ticketing-gin/features/users/data"
 	userHandler "e-ticketing-gin/features/users/handler"
@@ -12,6 +24,11 @@ import (
 	"e-ticketing-gin/helper/email"
 	"e-ticketing-gin/helper/enkrip"
 	"e-ticketing-gin/helper/jwt"
+	"e-ticketing-gin/helper/otp"
+	"e-ticketing-gin/helper/password"
+	"e-ticketing-gin/helper/storage"
+	"e-ticketing-gin/helper/totp"
+	"e-ticketing-gin/jobs"
 	"e-ticketing-gin/routes"
 	"e-ticketing-gin/server"
 	"e-ticketing-gin/utils/database"
@@ -24,25 +41,69 @@ var userSet = wire.NewSet(
 
 	userService.New,
 	wire.Bind(new(users.UserServiceInterface), new(*userService.UserService)),
+	wire.Bind(new(jwt.Denylist), new(*userService.UserService)),
+	wire.Bind(new(jwt.SessionTracker), new(*userService.UserService)),
+	wire.Bind(new(jwt.Revoker), new(*userData.UserData)),
 
 	userHandler.NewHandler,
 	wire.Bind(new(users.UserHandlerInterface), new(*userHandler.UserHandler)),
 )
 
-func InitializedServer() *server.Server {
+var roleSet = wire.NewSet(
+	roleData.New,
+	wire.Bind(new(roles.RoleDataInterface), new(*roleData.RoleData)),
+
+	roleService.New,
+	wire.Bind(new(roles.RoleServiceInterface), new(*roleService.RoleService)),
+
+	roleHandler.NewHandler,
+	wire.Bind(new(roles.RoleHandlerInterface), new(*roleHandler.RoleHandler)),
+)
+
+var outboxSet = wire.NewSet(
+	outboxData.New,
+	wire.Bind(new(outbox.OutboxDataInterface), new(*outboxData.OutboxData)),
+
+	outboxService.New,
+	wire.Bind(new(outbox.OutboxServiceInterface), new(*outboxService.OutboxService)),
+
+	outboxHandler.NewHandler,
+	wire.Bind(new(outbox.OutboxHandlerInterface), new(*outboxHandler.OutboxHandler)),
+)
+
+var auditSet = wire.NewSet(
+	auditData.New,
+	wire.Bind(new(audit.AuditDataInterface), new(*auditData.AuditData)),
+
+	auditService.New,
+	wire.Bind(new(audit.AuditServiceInterface), new(*auditService.AuditService)),
+
+	auditHandler.NewHandler,
+	wire.Bind(new(audit.AuditHandlerInterface), new(*auditHandler.AuditHandler)),
+)
+
+func InitializedServer() (*server.Server, error) {
 	wire.Build(
 		configs.InitConfig,
 		database.InitDB,
 		enkrip.New,
 		email.NewEmail,
 		jwt.NewJWT,
+		totp.NewTOTP,
+		otp.NewOTP,
+		password.NewPolicy,
+		storage.NewStorage,
 		//JANGAN DIUBAH
 
 		userSet,
+		roleSet,
+		outboxSet,
+		auditSet,
 
 		// JANGAN DIUBAH
 		routes.NewRoute,
+		jobs.NewJob,
 		server.InitServer,
 	)
-	return nil
+	return nil, nil
 }
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"e-ticketing-gin/features/audit"
+	"e-ticketing-gin/features/outbox"
+	"e-ticketing-gin/features/users"
+	"e-ticketing-gin/helper/jwt"
+	"e-ticketing-gin/utils/scheduler"
+	"time"
+)
+
+func NewJob(us users.UserServiceInterface, ob outbox.OutboxServiceInterface, au audit.AuditServiceInterface, j jwt.JWTInterface) *scheduler.Scheduler {
+	var s = scheduler.New()
+
+	s.Register(scheduler.Job{
+		Name:     "Deliver Outbox Emails",
+		Interval: time.Second * 10,
+		Run:      ob.Deliver,
+	})
+
+	s.Register(scheduler.Job{
+		Name:     "Purge Revoked Tokens",
+		Interval: time.Hour,
+		Run:      us.PurgeRevokedTokens,
+	})
+
+	s.Register(scheduler.Job{
+		Name:     "Purge Deleted Users",
+		Interval: time.Hour,
+		Run:      us.PurgeDeletedUsers,
+	})
+
+	s.Register(scheduler.Job{
+		Name:     "Audit Chain Checkpoint",
+		Interval: time.Hour,
+		Run:      au.Checkpoint,
+	})
+
+	s.Register(scheduler.Job{
+		Name:     "Rotate Signing Keys",
+		Interval: time.Hour,
+		Run:      j.RotateKeys,
+	})
+
+	return s
+}
@@ -1,9 +1,22 @@
 package main
 
+import (
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
 func main() {
-	server := InitializedServer()
+	server, err := InitializedServer()
+	if err != nil {
+		logrus.Fatal("Main : Cannot start program : ", err.Error())
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit" {
+		os.Exit(server.VerifyAudit())
+	}
 
 	server.MigrateDB()
 	server.SeederDB()
+	server.RunScheduler()
 	server.RunServer()
 }
@@ -1,49 +1,118 @@
 package routes
 
 import (
+	"e-ticketing-gin/features/audit"
+	"e-ticketing-gin/features/outbox"
+	"e-ticketing-gin/features/roles"
 	"e-ticketing-gin/features/users"
 	"e-ticketing-gin/helper"
 	"e-ticketing-gin/helper/cors"
+	"e-ticketing-gin/helper/jwt"
+	"e-ticketing-gin/helper/requestid"
+	"e-ticketing-gin/helper/storage"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"net/http"
-	"strings"
 )
 
-func NewRoute(uh users.UserHandlerInterface) *gin.Engine {
+func NewRoute(uh users.UserHandlerInterface, rh roles.RoleHandlerInterface, oh outbox.OutboxHandlerInterface, ah audit.AuditHandlerInterface, j jwt.JWTInterface, dl jwt.Denylist, st jwt.SessionTracker, fs storage.StorageInterface) *gin.Engine {
 	router := gin.Default()
 	router.Use(cors.Default())
+	router.Use(requestid.New())
+	fs.Mount(router)
 
-	jwtAuth := authMiddleware()
+	jwtAuth := authMiddleware(j, dl, st)
+
+	router.GET("/.well-known/jwks.json", jwksHandler(j))
 
 	api := router.Group("/api/v1")
 
 	// Route Authentication
 	api.POST("/register", uh.Register)
 	api.POST("/login", uh.Login)
+	api.POST("/login/mfa", uh.LoginMFA)
 	api.POST("/forget-password", uh.ForgetPasswordWeb)
 	api.POST("/reset-password", uh.ResetPassword)
-	api.POST("/refresh-token", jwtAuth, uh.RefreshToken)
+	api.POST("/refresh-token", uh.RefreshToken)
 	api.POST("/verification", uh.UserVerification)
+	api.POST("/verification/resend", uh.ResendVerification)
+	api.POST("/logout", jwtAuth, uh.Logout)
+	api.POST("/logout-all", jwtAuth, uh.LogoutAll)
 
 	// Route Profile
 	api.GET("/profile", jwtAuth, uh.Profile)
 	api.PUT("/profile/update", jwtAuth, uh.UpdateProfile)
+	api.PUT("/profile/avatar", jwtAuth, uh.UpdateAvatar)
+	api.POST("/profile/confirm-change", jwtAuth, uh.ConfirmContactChange)
+	api.PUT("/profile/password", jwtAuth, uh.ChangePassword)
+	api.DELETE("/profile", jwtAuth, uh.DeleteAccount)
+	api.GET("/profile/sessions", jwtAuth, uh.GetSessions)
+	api.DELETE("/profile/sessions/:id", jwtAuth, uh.RevokeSession)
+	api.POST("/profile/mfa/enroll", jwtAuth, uh.EnrollMFA)
+	api.POST("/profile/mfa/confirm", jwtAuth, uh.ConfirmMFA)
+	api.POST("/profile/mfa/recovery-codes", jwtAuth, uh.RegenerateRecoveryCodes)
+	api.DELETE("/profile/mfa", jwtAuth, uh.DisableMFA)
 
 	// Route User - Admin
-	api.GET("/user", jwtAuth, uh.GetUsers)
-	api.GET("/user/:id/activate", jwtAuth, uh.ActivateUser)
-	api.GET("/user/:id/deactivate", jwtAuth, uh.DeactivateUser)
-	api.GET("/user/dashboard", jwtAuth, uh.UserDashboard)
+	api.GET("/user", jwtAuth, jwt.RequirePermission(roles.PermUsersRead), uh.GetUsers)
+	api.GET("/user/export", jwtAuth, jwt.RequirePermission(roles.PermUsersExport), uh.ExportUsers)
+	api.POST("/user/import", jwtAuth, jwt.RequirePermission(roles.PermUsersImport), uh.ImportUsers)
+	api.GET("/user/:id/activate", jwtAuth, jwt.RequirePermission(roles.PermUsersActivate), uh.ActivateUser)
+	api.GET("/user/:id/deactivate", jwtAuth, jwt.RequirePermission(roles.PermUsersDeactivate), uh.DeactivateUser)
+	api.GET("/user/:id/sessions", jwtAuth, jwt.RequirePermission(roles.PermUsersSessions), uh.GetUserSessions)
+	api.DELETE("/user/:id/sessions/:session_id", jwtAuth, jwt.RequirePermission(roles.PermUsersSessions), uh.RevokeUserSession)
+	api.POST("/user/:id/unlock", jwtAuth, jwt.RequirePermission(roles.PermUsersUnlock), uh.UnlockUser)
+	api.DELETE("/user/:id", jwtAuth, jwt.RequirePermission(roles.PermUsersDelete), uh.DeleteUser)
+	api.POST("/user/:id/restore", jwtAuth, jwt.RequirePermission(roles.PermUsersDelete), uh.RestoreUser)
+	api.GET("/user/dashboard", jwtAuth, jwt.RequirePermission(roles.PermUsersDashboard), uh.UserDashboard)
+
+	// Route Email - Admin
+	api.GET("/email/templates", jwtAuth, jwt.RequirePermission(roles.PermEmailsPreview), uh.GetEmailTemplates)
+	api.GET("/email/templates/:name/preview", jwtAuth, jwt.RequirePermission(roles.PermEmailsPreview), uh.PreviewEmail)
+	api.GET("/email/outbox", jwtAuth, jwt.RequirePermission(roles.PermEmailsManage), oh.GetMessages)
+	api.GET("/email/outbox/:id", jwtAuth, jwt.RequirePermission(roles.PermEmailsManage), oh.GetMessage)
+	api.POST("/email/outbox/:id/resend", jwtAuth, jwt.RequirePermission(roles.PermEmailsManage), oh.Resend)
+
+	// Route Role - Admin
+	api.GET("/roles", jwtAuth, jwt.RequirePermission(roles.PermRolesRead), rh.GetRoles)
+	api.GET("/permissions", jwtAuth, jwt.RequirePermission(roles.PermRolesRead), rh.GetPermissions)
+	api.GET("/user/:id/roles", jwtAuth, jwt.RequirePermission(roles.PermRolesRead), rh.GetUserRoles)
+	api.POST("/user/:id/roles", jwtAuth, jwt.RequirePermission(roles.PermRolesAssign), rh.AssignRole)
+	api.DELETE("/user/:id/roles/:role", jwtAuth, jwt.RequirePermission(roles.PermRolesAssign), rh.RevokeRole)
+	api.PUT("/roles/:role/mfa", jwtAuth, jwt.RequirePermission(roles.PermRolesManage), rh.SetMFAPolicy)
+
+	// Route Audit - Admin
+	api.GET("/audit", jwtAuth, jwt.RequirePermission(roles.PermAuditRead), ah.GetEntries)
+	api.GET("/audit/export", jwtAuth, jwt.RequirePermission(roles.PermAuditRead), ah.Export)
+	api.GET("/audit/verify", jwtAuth, jwt.RequirePermission(roles.PermAuditRead), ah.Verify)
 
 	return router
 }
 
-func authMiddleware() gin.HandlerFunc {
+func jwksHandler(j jwt.JWTInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if !strings.Contains(authHeader, "Bearer") {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, j.JWKS())
+	}
+}
+
+func authMiddleware(j jwt.JWTInterface, dl jwt.Denylist, st jwt.SessionTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := j.ExtractToken(c)
+		if err != nil {
+			logrus.Error("Middleware : Unauthorized : ", err.Error())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
+			return
+		}
+
+		if dl.IsRevoked(principal) {
+			logrus.Error("Middleware : Unauthorized : Token Revoked")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, helper.FormatResponse("Unauthorized", nil))
 			return
 		}
+
+		st.Touch(principal, c.ClientIP())
+		jwt.SetPrincipal(c, principal)
+		c.Next()
 	}
 }
@@ -2,8 +2,10 @@ package server
 
 import (
 	"e-ticketing-gin/configs"
+	"e-ticketing-gin/features/audit"
 	"e-ticketing-gin/utils/database"
 	"e-ticketing-gin/utils/database/seeds"
+	"e-ticketing-gin/utils/scheduler"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -13,6 +15,8 @@ import (
 type Server struct {
 	g *gin.Engine
 	c *configs.ProgramConfig
+	s *scheduler.Scheduler
+	a audit.AuditServiceInterface
 }
 
 func (s *Server) RunServer() {
@@ -37,6 +41,27 @@ func (s *Server) RunServer() {
 	}
 }
 
+func (s *Server) RunScheduler() {
+	s.s.Start()
+}
+
+func (s *Server) VerifyAudit() int {
+	res, err := s.a.Verify()
+	if err != nil {
+		logrus.Error("Failed to verify audit chain : ", err.Error())
+		return 2
+	}
+
+	if !res.Valid {
+		fmt.Printf("Audit chain broken at record %d (checkpoint %d): %s\n", res.BrokenID, res.CheckpointID, res.Reason)
+		fmt.Printf("Records verified before the break: %d\n", res.Checked)
+		return 1
+	}
+
+	fmt.Printf("Audit chain intact: %d records, %d checkpoints verified\n", res.Checked, res.Checkpoints)
+	return 0
+}
+
 func (s *Server) MigrateDB() {
 	db := database.InitDB(s.c)
 	database.Migrate(db)
@@ -51,9 +76,11 @@ func (s *Server) SeederDB() {
 	}
 }
 
-func InitServer(g *gin.Engine, c *configs.ProgramConfig) *Server {
+func InitServer(g *gin.Engine, c *configs.ProgramConfig, s *scheduler.Scheduler, a audit.AuditServiceInterface) *Server {
 	return &Server{
 		g: g,
 		c: c,
+		s: s,
+		a: a,
 	}
 }
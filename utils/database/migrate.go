@@ -1,12 +1,164 @@
 package database
 
 import (
+	auditData "e-ticketing-gin/features/audit/data"
+	"e-ticketing-gin/features/outbox"
+	outboxData "e-ticketing-gin/features/outbox/data"
+	roleData "e-ticketing-gin/features/roles/data"
 	"e-ticketing-gin/features/users/data"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"strings"
 )
 
 func Migrate(db *gorm.DB) {
+	var legacyActivation = db.Migrator().HasTable(&data.User{}) && !db.Migrator().HasColumn(&data.User{}, "verified_at")
+	var unverified = legacyUnverified(db, legacyActivation)
+
+	dropPlaintextCodes(db, &data.UserResetPass{})
+	dropPlaintextCodes(db, &data.UserVerification{})
+	normalizeIdentities(db)
+
 	db.AutoMigrate(data.User{})
 	db.AutoMigrate(data.UserResetPass{})
 	db.AutoMigrate(data.UserVerification{})
+	backfillActivation(db, legacyActivation, unverified)
+	db.AutoMigrate(data.VerificationRequest{})
+	db.AutoMigrate(data.UserContactChange{})
+	db.AutoMigrate(data.UserPasswordHistory{})
+	db.AutoMigrate(data.UserRefreshToken{})
+	db.AutoMigrate(data.UserSession{})
+	db.AutoMigrate(data.RevokedToken{})
+	db.AutoMigrate(data.UserMFA{})
+	db.AutoMigrate(data.UserRecoveryCode{})
+	db.AutoMigrate(data.LoginAttempt{})
+	db.AutoMigrate(data.LockoutEvent{})
+
+	db.AutoMigrate(roleData.Role{})
+	db.AutoMigrate(roleData.Permission{})
+	db.AutoMigrate(roleData.RolePermission{})
+	db.AutoMigrate(roleData.UserRole{})
+
+	var legacyOutbox = db.Migrator().HasTable(&outboxData.EmailOutbox{}) && !db.Migrator().HasColumn(&outboxData.EmailOutbox{}, "sensitive")
+	db.AutoMigrate(outboxData.EmailOutbox{})
+	if legacyOutbox {
+		redactLegacyOutbox(db)
+	}
+
+	db.AutoMigrate(auditData.AuditLog{})
+	db.AutoMigrate(auditData.AuditCheckpoint{})
+	if err := auditData.Backfill(db); err != nil {
+		logrus.Error("Database : Backfill Audit Chain Error : ", err.Error())
+	}
+	protectAuditLogs(db)
+}
+
+func dropPlaintextCodes(db *gorm.DB, model any) {
+	if !db.Migrator().HasColumn(model, "code") {
+		return
+	}
+
+	if err := db.Migrator().DropTable(model); err != nil {
+		logrus.Error("Database : Drop Plaintext Code Table Error : ", err.Error())
+	}
+}
+
+func legacyUnverified(db *gorm.DB, legacy bool) []string {
+	if !legacy || !db.Migrator().HasTable(&data.UserVerification{}) {
+		return nil
+	}
+
+	var result []string
+	if err := db.Raw("SELECT DISTINCT LOWER(username) FROM user_verifications").Scan(&result).Error; err != nil {
+		logrus.Error("Database : Read Legacy Verifications Error : ", err.Error())
+	}
+
+	return result
+}
+
+func backfillActivation(db *gorm.DB, legacy bool, unverified []string) {
+	var qry = db.Exec("UPDATE users SET verified_at = created_at, activated_at = created_at WHERE status AND verified_at IS NULL AND activated_at IS NULL")
+	if err := qry.Error; err != nil {
+		logrus.Error("Database : Backfill Activation Error : ", err.Error())
+	}
+
+	if !legacy {
+		return
+	}
+
+	var qryDeactivated = db.Unscoped().Model(&data.User{}).Where("NOT status AND verified_at IS NULL AND activated_at IS NULL")
+	if len(unverified) > 0 {
+		qryDeactivated = qryDeactivated.Where("LOWER(username) NOT IN ?", unverified)
+	}
+	if err := qryDeactivated.UpdateColumn("verified_at", gorm.Expr("created_at")).Error; err != nil {
+		logrus.Error("Database : Backfill Deactivated Verification Error : ", err.Error())
+	}
+}
+
+func normalizeIdentities(db *gorm.DB) {
+	if !db.Migrator().HasTable(&data.User{}) {
+		return
+	}
+
+	var qry = db.Exec("UPDATE users SET username = TRIM(username), email = LOWER(TRIM(email)) WHERE username <> TRIM(username) OR email <> LOWER(TRIM(email))")
+	if err := qry.Error; err != nil {
+		logrus.Error("Database : Normalize Identities Error : ", err.Error())
+		return
+	}
+
+	for _, column := range []string{"username", "email"} {
+		var duplicates []string
+		if err := db.Raw("SELECT LOWER(" + column + ") FROM users GROUP BY LOWER(" + column + ") HAVING COUNT(*) > 1").Scan(&duplicates).Error; err != nil {
+			logrus.Error("Database : Check Duplicate Identities Error : ", err.Error())
+			continue
+		}
+
+		if len(duplicates) > 0 {
+			logrus.Error("Database : Duplicate ", column, " must be resolved before the unique index can be created : ", strings.Join(duplicates, ", "))
+		}
+	}
+}
+
+func redactLegacyOutbox(db *gorm.DB) {
+	if err := db.Model(&outboxData.EmailOutbox{}).Where("1 = 1").Update("sensitive", true).Error; err != nil {
+		logrus.Error("Database : Mark Legacy Outbox Sensitive Error : ", err.Error())
+		return
+	}
+
+	var qry = db.Model(&outboxData.EmailOutbox{}).Where("status <> ?", outbox.StatusPending).Updates(map[string]any{"html": "", "text": ""})
+	if err := qry.Error; err != nil {
+		logrus.Error("Database : Redact Legacy Outbox Error : ", err.Error())
+	}
+}
+
+func protectAuditLogs(db *gorm.DB) {
+	var function = `CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+		END;
+		$$ LANGUAGE plpgsql`
+	if err := db.Exec(function).Error; err != nil {
+		logrus.Error("Database : Protect Audit Logs Error : ", err.Error())
+		return
+	}
+
+	for _, table := range []string{"audit_logs", "audit_checkpoints"} {
+		var err = db.Transaction(func(tx *gorm.DB) error {
+			var statements = []string{
+				"DROP TRIGGER IF EXISTS " + table + "_append_only ON " + table,
+				"CREATE TRIGGER " + table + "_append_only BEFORE UPDATE OR DELETE ON " + table + " FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()",
+				"DROP TRIGGER IF EXISTS " + table + "_no_truncate ON " + table,
+				"CREATE TRIGGER " + table + "_no_truncate BEFORE TRUNCATE ON " + table + " FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()",
+			}
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			logrus.Error("Database : Protect ", table, " Error : ", err.Error())
+		}
+	}
 }
@@ -0,0 +1,78 @@
+package seeds
+
+import (
+	"e-ticketing-gin/features/roles"
+	"e-ticketing-gin/features/roles/data"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func CreateRoles(db *gorm.DB) error {
+	for name, description := range roles.DefaultPermissions {
+		var permission = &data.Permission{Name: name, Description: description}
+		if err := db.Where(data.Permission{Name: name}).FirstOrCreate(permission).Error; err != nil {
+			return err
+		}
+	}
+
+	for name, permissions := range roles.DefaultRoles {
+		var role = &data.Role{Name: name}
+		if err := db.Where(data.Role{Name: name}).FirstOrCreate(role).Error; err != nil {
+			return err
+		}
+
+		for _, permissionName := range permissions {
+			var permission = new(data.Permission)
+			if err := db.Where("name = ?", permissionName).First(permission).Error; err != nil {
+				return err
+			}
+
+			var rolePermission = data.RolePermission{RoleID: role.ID, PermissionID: permission.ID}
+			if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rolePermission).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func AssignRole(db *gorm.DB, userID uint, roleName string) error {
+	var role = new(data.Role)
+	if err := db.Where("name = ?", roleName).First(role).Error; err != nil {
+		return err
+	}
+
+	var userRole = data.UserRole{UserID: userID, RoleID: role.ID}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&userRole).Error
+}
+
+func MigrateAdminFlag(db *gorm.DB) error {
+	if !db.Migrator().HasColumn("users", "is_admin") {
+		return nil
+	}
+
+	var userIDs []uint
+	if err := db.Table("users").Where("is_admin = ?", true).Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	var customerIDs []uint
+	if err := db.Table("users").Where("is_admin = ?", false).Pluck("id", &customerIDs).Error; err != nil {
+		return err
+	}
+
+	for _, id := range userIDs {
+		if err := AssignRole(db, id, roles.RoleAdmin); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range customerIDs {
+		if err := AssignRole(db, id, roles.RoleCustomer); err != nil {
+			return err
+		}
+	}
+
+	return db.Migrator().DropColumn("users", "is_admin")
+}
@@ -7,6 +7,18 @@ import (
 
 func All() []seed.Seed {
 	var seeds []seed.Seed = []seed.Seed{
+		{
+			Name: "Create Roles",
+			Run: func(db *gorm.DB) error {
+				return CreateRoles(db)
+			},
+		},
+		{
+			Name: "Migrate Admin Flag",
+			Run: func(db *gorm.DB) error {
+				return MigrateAdminFlag(db)
+			},
+		},
 		{
 			Name: "Create Admin",
 			Run: func(db *gorm.DB) error {
@@ -1,6 +1,7 @@
 package seeds
 
 import (
+	"e-ticketing-gin/features/roles"
 	"e-ticketing-gin/features/users"
 	"e-ticketing-gin/helper/enkrip"
 	"gorm.io/gorm"
@@ -11,16 +12,22 @@ func CreateUser(db *gorm.DB, username, email, phoneNumber string) error {
 	db.Table("users").Where("email = ?", email).Where("username = ?", username).Count(&countData)
 
 	if countData < 1 {
-		hash := enkrip.New()
-		hashPass, _ := hash.HashPassword("password")
-		return db.Create(&users.User{
+		hash := enkrip.NewWithParams(enkrip.DefaultParams())
+		hashPass, err := hash.HashPassword("password")
+		if err != nil {
+			return err
+		}
+		var newUser = &users.User{
 			Username:    username,
 			Email:       email,
 			Password:    hashPass,
 			PhoneNumber: phoneNumber,
-			IsAdmin:     true,
 			Status:      true,
-		}).Error
+		}
+		if err := db.Create(newUser).Error; err != nil {
+			return err
+		}
+		return AssignRole(db, newUser.ID, roles.RoleAdmin)
 	}
 	return nil
 }
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+type Scheduler struct {
+	jobs []Job
+}
+
+func New(jobs ...Job) *Scheduler {
+	return &Scheduler{
+		jobs: jobs,
+	}
+}
+
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+func (s *Scheduler) Start() {
+	for _, job := range s.jobs {
+		go s.run(job)
+	}
+}
+
+func (s *Scheduler) run(job Job) {
+	var ticker = time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(); err != nil {
+			logrus.Error("Scheduler : Job '", job.Name, "' Error : ", err.Error())
+		}
+		<-ticker.C
+	}
+}
@@ -8,6 +8,18 @@ package main
 
 import (
 	"e-ticketing-gin/configs"
+	"e-ticketing-gin/features/audit"
+	data4 "e-ticketing-gin/features/audit/data"
+	handler4 "e-ticketing-gin/features/audit/handler"
+	service4 "e-ticketing-gin/features/audit/service"
+	"e-ticketing-gin/features/outbox"
+	data3 "e-ticketing-gin/features/outbox/data"
+	handler3 "e-ticketing-gin/features/outbox/handler"
+	service3 "e-ticketing-gin/features/outbox/service"
+	"e-ticketing-gin/features/roles"
+	data2 "e-ticketing-gin/features/roles/data"
+	handler2 "e-ticketing-gin/features/roles/handler"
+	service2 "e-ticketing-gin/features/roles/service"
 	"e-ticketing-gin/features/users"
 	"e-ticketing-gin/features/users/data"
 	"e-ticketing-gin/features/users/handler"
@@ -15,6 +27,11 @@ import (
 	"e-ticketing-gin/helper/email"
 	"e-ticketing-gin/helper/enkrip"
 	"e-ticketing-gin/helper/jwt"
+	"e-ticketing-gin/helper/otp"
+	"e-ticketing-gin/helper/password"
+	"e-ticketing-gin/helper/storage"
+	"e-ticketing-gin/helper/totp"
+	"e-ticketing-gin/jobs"
 	"e-ticketing-gin/routes"
 	"e-ticketing-gin/server"
 	"e-ticketing-gin/utils/database"
@@ -23,20 +40,46 @@ import (
 
 // Injectors from injector.go:
 
-func InitializedServer() *server.Server {
+func InitializedServer() (*server.Server, error) {
 	programConfig := configs.InitConfig()
-	jwtInterface := jwt.NewJWT(programConfig)
+	jwtInterface, err := jwt.NewJWT(programConfig)
+	if err != nil {
+		return nil, err
+	}
 	db := database.InitDB(programConfig)
-	hashInterface := enkrip.New()
+	hashInterface := enkrip.New(programConfig)
 	userData := data.New(db, hashInterface)
 	emailInterface := email.NewEmail(programConfig)
-	userService := service.New(userData, hashInterface, jwtInterface, emailInterface)
-	userHandler := handler.NewHandler(jwtInterface, userService)
-	engine := routes.NewRoute(userHandler)
-	serverServer := server.InitServer(engine, programConfig)
-	return serverServer
+	auditData := data4.New(db)
+	auditService := service4.New(auditData, programConfig)
+	roleData := data2.New(db)
+	roleService := service2.New(roleData, auditService, userData)
+	totpInterface := totp.NewTOTP(programConfig)
+	otpInterface := otp.NewOTP(programConfig)
+	storageInterface, err := storage.NewStorage(programConfig)
+	if err != nil {
+		return nil, err
+	}
+	userService := service.New(userData, hashInterface, jwtInterface, emailInterface, roleService, auditService, totpInterface, otpInterface, storageInterface, programConfig)
+	policyInterface := password.NewPolicy(programConfig)
+	userHandler := handler.NewHandler(jwtInterface, userService, policyInterface)
+	roleHandler := handler2.NewHandler(roleService)
+	outboxData := data3.New(db)
+	outboxService := service3.New(outboxData, emailInterface, programConfig)
+	outboxHandler := handler3.NewHandler(outboxService)
+	auditHandler := handler4.NewHandler(auditService)
+	engine := routes.NewRoute(userHandler, roleHandler, outboxHandler, auditHandler, jwtInterface, userService, userService, storageInterface)
+	scheduler := jobs.NewJob(userService, outboxService, auditService, jwtInterface)
+	serverServer := server.InitServer(engine, programConfig, scheduler, auditService)
+	return serverServer, nil
 }
 
 // injector.go:
 
-var userSet = wire.NewSet(data.New, wire.Bind(new(users.UserDataInterface), new(*data.UserData)), service.New, wire.Bind(new(users.UserServiceInterface), new(*service.UserService)), handler.NewHandler, wire.Bind(new(users.UserHandlerInterface), new(*handler.UserHandler)))
+var userSet = wire.NewSet(data.New, wire.Bind(new(users.UserDataInterface), new(*data.UserData)), service.New, wire.Bind(new(users.UserServiceInterface), new(*service.UserService)), wire.Bind(new(jwt.Denylist), new(*service.UserService)), wire.Bind(new(jwt.SessionTracker), new(*service.UserService)), wire.Bind(new(jwt.Revoker), new(*data.UserData)), handler.NewHandler, wire.Bind(new(users.UserHandlerInterface), new(*handler.UserHandler)))
+
+var roleSet = wire.NewSet(data2.New, wire.Bind(new(roles.RoleDataInterface), new(*data2.RoleData)), service2.New, wire.Bind(new(roles.RoleServiceInterface), new(*service2.RoleService)), handler2.NewHandler, wire.Bind(new(roles.RoleHandlerInterface), new(*handler2.RoleHandler)))
+
+var outboxSet = wire.NewSet(data3.New, wire.Bind(new(outbox.OutboxDataInterface), new(*data3.OutboxData)), service3.New, wire.Bind(new(outbox.OutboxServiceInterface), new(*service3.OutboxService)), handler3.NewHandler, wire.Bind(new(outbox.OutboxHandlerInterface), new(*handler3.OutboxHandler)))
+
+var auditSet = wire.NewSet(data4.New, wire.Bind(new(audit.AuditDataInterface), new(*data4.AuditData)), service4.New, wire.Bind(new(audit.AuditServiceInterface), new(*service4.AuditService)), handler4.NewHandler, wire.Bind(new(audit.AuditHandlerInterface), new(*handler4.AuditHandler)))